@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/redis/go-redis/v9"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -73,7 +74,9 @@ const (
 	UserCachePrefix = "user:" // user:123
 
 	// Category caching
-	CategoryCacheKey = "categories:all" // все категории
+	CategoryCacheKey     = "categories:all"  // все категории
+	CategoryTreeCacheKey = "categories:tree" // дерево категорий
+	CategorySubtreeKey   = ":subtree"        // games:cat:5:subtree - игры категории и подкатегорий
 
 	// Reviews caching
 	ReviewsCachePrefix = "reviews:game:" // reviews:game:123
@@ -86,6 +89,16 @@ const (
 
 	// Rate limiting
 	RateLimitPrefix = "ratelimit:user:" // ratelimit:user:123
+	ThrottlePrefix  = "ratelimit:"      // ratelimit:redeem:ip:1.2.3.4
+
+	// Slug to ID lookups
+	SlugCachePrefix = "slug:" // slug:game:half-life-2
+
+	// Responses stored for Idempotency-Key retries
+	IdempotencyPrefix = "idempotency:" // idempotency:user:12:<key>
+
+	// Family sharing leases, one per lent copy
+	LeasePrefix = "lease:" // lease:family:12:34 (lender 12, game 34)
 )
 
 // ==================== GENERIC CACHE OPERATIONS ====================
@@ -125,6 +138,21 @@ func Get(key string, dest interface{}) error {
 	return nil
 }
 
+// SetIfAbsent stores value with TTL unless the key exists, and reports
+// whether it was stored
+func SetIfAbsent(key string, value interface{}, ttl time.Duration) (bool, error) {
+	if !IsRedisAvailable() {
+		return false, fmt.Errorf("redis not available")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	return RedisClient.SetNX(ctx, key, data, ttl).Result()
+}
+
 // Delete removes key from cache
 func Delete(key string) error {
 	if !IsRedisAvailable() {
@@ -216,6 +244,20 @@ func SetGamesByCategory(categoryID uint, games interface{}) error {
 	return Set(key, games, 5*time.Minute)
 }
 
+// GetGamesByCategorySubtree returns cached games for a category and its descendants
+func GetGamesByCategorySubtree(categoryID uint) (interface{}, error) {
+	key := fmt.Sprintf("%s%d%s", GamesByCategoryKey, categoryID, CategorySubtreeKey)
+	var games interface{}
+	err := Get(key, &games)
+	return games, err
+}
+
+// SetGamesByCategorySubtree caches games for a category and its descendants
+func SetGamesByCategorySubtree(categoryID uint, games interface{}) error {
+	key := fmt.Sprintf("%s%d%s", GamesByCategoryKey, categoryID, CategorySubtreeKey)
+	return Set(key, games, 5*time.Minute)
+}
+
 // ==================== USER CACHING ====================
 
 // GetUser returns cached user
@@ -252,9 +294,57 @@ func SetCategories(categories interface{}) error {
 	return Set(CategoryCacheKey, categories, time.Hour)
 }
 
-// InvalidateCategories removes categories cache
-func InvalidateCategories() error {
-	return Delete(CategoryCacheKey)
+// GetCategoryTree returns the cached category tree
+func GetCategoryTree() (interface{}, error) {
+	var tree interface{}
+	err := Get(CategoryTreeCacheKey, &tree)
+	return tree, err
+}
+
+// SetCategoryTree caches the category tree for 1 hour
+func SetCategoryTree(tree interface{}) error {
+	return Set(CategoryTreeCacheKey, tree, time.Hour)
+}
+
+// InvalidateCategories removes categories cache and the game lists of the
+// given categories. Callers pass the whole affected subtree and its ancestors,
+// whose descendant listings include it.
+func InvalidateCategories(categoryIDs ...uint) error {
+	if err := Delete(CategoryCacheKey); err != nil {
+		return err
+	}
+	if err := Delete(CategoryTreeCacheKey); err != nil {
+		return err
+	}
+	for _, id := range categoryIDs {
+		key := fmt.Sprintf("%s%d", GamesByCategoryKey, id)
+		if err := Delete(key); err != nil {
+			return err
+		}
+		if err := Delete(key + CategorySubtreeKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ==================== SLUG CACHING ====================
+
+// GetSlugID returns the cached ID an entity slug points to
+func GetSlugID(entity, slug string) (uint, error) {
+	var id uint
+	err := Get(SlugCachePrefix+entity+":"+slug, &id)
+	return id, err
+}
+
+// SetSlugID caches a slug to ID mapping for 1 hour
+func SetSlugID(entity, slug string, id uint) error {
+	return Set(SlugCachePrefix+entity+":"+slug, id, time.Hour)
+}
+
+// InvalidateSlug removes a slug to ID mapping
+func InvalidateSlug(entity, slug string) error {
+	return Delete(SlugCachePrefix + entity + ":" + slug)
 }
 
 // ==================== REVIEWS CACHING ====================
@@ -281,12 +371,10 @@ func InvalidateReviews(gameID uint) error {
 
 // ==================== LIBRARY CACHING ====================
 
-// GetUserLibrary returns cached user library
-func GetUserLibrary(userID uint) (interface{}, error) {
+// GetUserLibrary reads the cached user library into library
+func GetUserLibrary(userID uint, library interface{}) error {
 	key := fmt.Sprintf("%s%d", LibraryCachePrefix, userID)
-	var library interface{}
-	err := Get(key, &library)
-	return library, err
+	return Get(key, library)
 }
 
 // SetUserLibrary caches user library for 5 minutes
@@ -359,12 +447,136 @@ func CheckRateLimit(userID uint, maxRequests int, window time.Duration) (bool, i
 	return true, remaining, nil
 }
 
+// allowAttemptScript counts an attempt and starts the window with the
+// first one in a single step, so no counter is left without a TTL
+var allowAttemptScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 or redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// AllowAttempt counts an attempt under the key and reports whether it is
+// still within maxAttempts for the window, which starts at the first attempt.
+// Without Redis the attempt can't be counted and an error is returned.
+func AllowAttempt(key string, maxAttempts int, window time.Duration) (bool, error) {
+	if !IsRedisAvailable() {
+		return false, fmt.Errorf("redis not available")
+	}
+
+	count, err := allowAttemptScript.Run(ctx, RedisClient, []string{ThrottlePrefix + key}, window.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return count <= int64(maxAttempts), nil
+}
+
 // ResetRateLimit resets rate limit for a user
 func ResetRateLimit(userID uint) error {
 	key := fmt.Sprintf("%s%d", RateLimitPrefix, userID)
 	return Delete(key)
 }
 
+// ==================== LEASES ====================
+
+// acquireLeaseScript takes a free lease or renews one the holder already has,
+// and returns whoever holds it afterwards
+var acquireLeaseScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or current == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return ARGV[1]
+end
+return current
+`)
+
+// takeLeaseScript hands the lease to the holder whoever has it, and returns
+// the previous holder
+var takeLeaseScript = redis.NewScript(`
+local previous = redis.call('GET', KEYS[1])
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return previous or '0'
+`)
+
+// releaseLeaseScript deletes the lease only if the holder still has it
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// AcquireLease takes the lease under key for holder, or extends it when
+// holder already has it. Returns the holder of the lease afterwards, which
+// is someone else when it was taken.
+func AcquireLease(key string, holder uint, ttl time.Duration) (uint, error) {
+	if !IsRedisAvailable() {
+		return 0, fmt.Errorf("redis not available")
+	}
+
+	current, err := acquireLeaseScript.Run(ctx, RedisClient, []string{LeasePrefix + key}, holder, ttl.Milliseconds()).Text()
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(current, 10, 64)
+	return uint(id), err
+}
+
+// TakeLease gives the lease under key to holder even if someone else has
+// it, and returns who had it before, 0 if nobody
+func TakeLease(key string, holder uint, ttl time.Duration) (uint, error) {
+	if !IsRedisAvailable() {
+		return 0, fmt.Errorf("redis not available")
+	}
+
+	previous, err := takeLeaseScript.Run(ctx, RedisClient, []string{LeasePrefix + key}, holder, ttl.Milliseconds()).Text()
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(previous, 10, 64)
+	return uint(id), err
+}
+
+// LeaseHolder returns who holds the lease, 0 if nobody
+func LeaseHolder(key string) (uint, time.Duration, error) {
+	if !IsRedisAvailable() {
+		return 0, 0, fmt.Errorf("redis not available")
+	}
+
+	holder, err := RedisClient.Get(ctx, LeasePrefix+key).Uint64()
+	if err == redis.Nil {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	ttl, err := RedisClient.PTTL(ctx, LeasePrefix+key).Result()
+	return uint(holder), ttl, err
+}
+
+// DropLeases ends the leases matching pattern, e.g. "family:12:*". With a
+// holder only that holder's leases are dropped.
+func DropLeases(pattern string, holder uint) error {
+	if !IsRedisAvailable() {
+		return nil
+	}
+
+	iter := RedisClient.Scan(ctx, 0, LeasePrefix+pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		if holder == 0 {
+			if err := RedisClient.Del(ctx, iter.Val()).Err(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := releaseLeaseScript.Run(ctx, RedisClient, []string{iter.Val()}, holder).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
 // ==================== CACHE WARMING ====================
 
 // WarmCache preloads commonly accessed data
@@ -4,8 +4,12 @@ import (
 	"awesomeProject/cache"
 	"awesomeProject/db"
 	"awesomeProject/handlers"
+	"awesomeProject/mail"
 	"awesomeProject/middleware"
 	"awesomeProject/monitoring"
+	"awesomeProject/payments"
+	"awesomeProject/refunds"
+	"awesomeProject/revenue"
 	"awesomeProject/utils"
 	"crypto/tls"
 	"github.com/gin-contrib/cors"
@@ -14,6 +18,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 )
 
 func main() {
@@ -46,6 +51,36 @@ func main() {
 		}
 	}()
 
+	// Initialize mail senders
+	if err := mail.Init(); err != nil {
+		log.Fatal("failed to initialize mail:", err)
+	}
+
+	// Load the refund policy
+	if err := refunds.Init(); err != nil {
+		log.Fatal("failed to load refund policy:", err)
+	}
+
+	// Load the default platform fee
+	if err := revenue.Init(); err != nil {
+		log.Fatal("failed to load platform fee:", err)
+	}
+
+	// Load family sharing limits
+	if err := handlers.InitFamilySharing(); err != nil {
+		log.Fatal("failed to load family sharing settings:", err)
+	}
+
+	// Initialize payment providers; store credit is paid through the wallet
+	payments.Register(handlers.WalletProvider())
+	if err := payments.Init(); err != nil {
+		log.Fatal("failed to initialize payments:", err)
+	}
+	utils.Log.Info("💳 Payment provider: " + payments.Default().Name())
+
+	// Tell wishlisters when their games come out
+	go handlers.WatchReleases(time.Minute)
+
 	// Initialize Prometheus metrics
 	monitoring.InitMetrics()
 	utils.Log.Info("📊 Prometheus metrics initialized")
@@ -69,12 +104,15 @@ func main() {
 	r.Use(middleware.RateLimitInfo())
 	r.Use(middleware.RemovePoweredBy())
 
+	// Response language negotiation (Accept-Language)
+	r.Use(utils.LanguageMiddleware())
+
 	// CORS Configuration
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "https://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposeHeaders:    []string{"Content-Length", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Window"},
+		AllowHeaders:     []string{"Authorization", "Content-Type", "X-CSRF-Token", "Accept-Language", "X-Cart-Token", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Window", "Content-Language", "X-Cart-Token", "Idempotent-Replayed"},
 		AllowCredentials: true,
 	}))
 
@@ -110,27 +148,123 @@ func main() {
 		public.GET("/games/:id", handlers.GetGameByID)
 		public.GET("/games/search", handlers.SearchGames) // Search endpoint
 		public.GET("/categories", handlers.GetCategories)
+		public.GET("/categories/:id", handlers.GetCategoryByID)
 		public.GET("/reviews", handlers.GetReviews)
+		public.GET("/bundles", handlers.GetBundles)
+		public.GET("/bundles/:id", handlers.GetBundleByID)
+		public.GET("/organizations", handlers.GetOrganizations)
+		public.GET("/organizations/:id", handlers.GetOrganizationByID)
+		public.GET("/series", handlers.GetSeriesList)
+		public.GET("/series/:id", handlers.GetSeriesByID)
+		public.POST("/payments/webhook/:provider", handlers.PaymentWebhook)
+	}
+
+	// ==================== CART ROUTES ====================
+	// Work for guests too: anonymous carts are identified by X-Cart-Token
+	cart := r.Group("/cart")
+	cart.Use(handlers.OptionalAuthMiddleware())
+	cart.Use(middleware.CSRFProtection())
+	cart.Use(middleware.Idempotency(24 * time.Hour))
+	{
+		cart.GET("", handlers.GetCart)
+		cart.DELETE("", handlers.ClearCart)
+		cart.POST("/items", handlers.AddCartItem)
+		cart.DELETE("/items/:gameId", handlers.RemoveCartItem)
 	}
 
 	// ==================== PROTECTED ROUTES ====================
 	protected := r.Group("/")
 	protected.Use(handlers.AuthMiddleware())
 	protected.Use(middleware.CSRFProtection())
+	protected.Use(middleware.Idempotency(24 * time.Hour))
 	{
 		// Game management
 		protected.POST("/games", handlers.CreateGame)
 		protected.PUT("/games/:id", handlers.UpdateGame)
 		protected.DELETE("/games/:id", handlers.DeleteGame)
+		protected.GET("/games/:id/revisions", handlers.GetGameRevisions)
+		protected.GET("/games/:id/revisions/:revisionId", handlers.GetGameRevision)
+		protected.POST("/games/:id/revisions/:revisionId/rollback", handlers.RollbackGame)
+
+		// Activation keys
+		protected.POST("/games/:id/key-batches", handlers.CreateKeyBatch)
+		protected.GET("/games/:id/key-batches", handlers.GetKeyBatches)
+		protected.GET("/games/:id/key-batches/:batchId/keys", handlers.DownloadKeyBatch)
+		protected.POST("/redeem", middleware.Throttle("redeem", 10, 10*time.Minute), handlers.RedeemKey)
+		protected.GET("/redeem", handlers.GetRedeemedKeys)
 
 		// Ownership
 		protected.DELETE("/ownership", handlers.ReturnGame)
+		protected.POST("/ownership/sessions", handlers.StartPlaySession)
+		protected.POST("/ownership/sessions/:id/heartbeat", handlers.HeartbeatPlaySession)
+		protected.POST("/ownership/sessions/:id/end", handlers.EndPlaySession)
+		protected.GET("/refunds", handlers.GetRefunds)
+		protected.GET("/gifts", handlers.GetGifts)
+		protected.GET("/gifts/sent", handlers.GetSentGifts)
+		protected.POST("/gifts/:id/accept", handlers.AcceptGift)
+		protected.POST("/gifts/:id/decline", handlers.DeclineGift)
 		protected.GET("/library", handlers.GetLibrary)
 		protected.POST("/ownership", handlers.BuyGame)
 
+		// Wishlist
+		protected.GET("/wishlist", handlers.GetWishlist)
+		protected.POST("/wishlist", handlers.AddToWishlist)
+		protected.PUT("/wishlist/order", handlers.ReorderWishlist)
+		protected.PUT("/wishlist/:gameId", handlers.UpdateWishlistEntry)
+		protected.DELETE("/wishlist/:gameId", handlers.RemoveFromWishlist)
+		protected.GET("/notifications", handlers.GetNotifications)
+		protected.POST("/notifications/read", handlers.MarkAllNotificationsRead)
+		protected.POST("/notifications/:id/read", handlers.MarkNotificationRead)
+
+		// Family sharing
+		protected.GET("/family", handlers.GetFamily)
+		protected.POST("/family", handlers.CreateFamily)
+		protected.DELETE("/family", handlers.DisbandFamily)
+		protected.POST("/family/members", handlers.InviteFamilyMember)
+		protected.DELETE("/family/members/:userId", handlers.RemoveFamilyMember)
+		protected.POST("/family/invitations/:id/accept", handlers.AcceptFamilyInvite)
+		protected.POST("/family/invitations/:id/decline", handlers.DeclineFamilyInvite)
+		protected.PUT("/family/sharing", handlers.SetFamilySharing)
+		protected.POST("/family/leases", handlers.BorrowSharedGame)
+		protected.DELETE("/family/leases/:gameId", handlers.ReturnSharedGame)
+
+		// Orders
+		protected.GET("/orders", handlers.GetOrders)
+		protected.GET("/orders/:id", handlers.GetOrderByID)
+		protected.GET("/purchases", handlers.GetPurchaseHistory)
+		protected.GET("/receipts/:id", handlers.GetReceipt)
+		protected.POST("/receipts/:id/email", handlers.EmailReceipt)
+		protected.POST("/cart/checkout", handlers.CheckoutCart)
+		protected.GET("/wallet", handlers.GetWallet)
+
 		// 🆕 CONCURRENT: Library with detailed info
 		protected.GET("/library/detailed", handlers.GetUserLibraryWithDetails)
 
+		// Bundles
+		protected.POST("/bundles", handlers.CreateBundle)
+		protected.DELETE("/bundles/:id", handlers.DeleteBundle)
+		protected.GET("/bundles/:id/price", handlers.GetBundlePrice)
+		protected.POST("/bundles/:id/purchase", handlers.PurchaseBundle)
+
+		// Organizations (studios / publishers)
+		protected.POST("/organizations", handlers.CreateOrganization)
+		protected.PUT("/organizations/:id", handlers.UpdateOrganization)
+		protected.GET("/organizations/:id/members", handlers.GetOrganizationMembers)
+		protected.POST("/organizations/:id/members", handlers.AddOrganizationMember)
+		protected.DELETE("/organizations/:id/members/:userId", handlers.RemoveOrganizationMember)
+
+		// Developer earnings
+		protected.GET("/earnings", handlers.GetEarnings)
+		protected.GET("/earnings/statements", handlers.GetEarningsStatement)
+		protected.GET("/earnings/payouts", handlers.GetPayouts)
+		protected.POST("/earnings/payouts", handlers.RequestPayout)
+
+		// Series / franchises
+		protected.POST("/series", handlers.CreateSeries)
+		protected.PUT("/series/:id/entries", handlers.UpdateSeriesEntries)
+		protected.DELETE("/series/:id", handlers.DeleteSeries)
+		protected.GET("/series/:id/progress", handlers.GetSeriesProgress)
+
 		// Categories
 		protected.POST("/categories", handlers.CreateCategory)
 		protected.PUT("/categories/:id", handlers.UpdateCategory)
@@ -146,6 +280,7 @@ func main() {
 
 		// Reviews
 		protected.POST("/reviews", handlers.CreateReview)
+		protected.PUT("/reviews/:id", handlers.UpdateReview)
 		protected.DELETE("/reviews/:id", handlers.DeleteReview)
 
 		// 🆕 CONCURRENT: Advanced game details
@@ -156,6 +291,7 @@ func main() {
 	admin := r.Group("/admin")
 	admin.Use(handlers.AuthMiddleware())
 	admin.Use(middleware.CSRFProtection())
+	admin.Use(middleware.Idempotency(24 * time.Hour))
 	{
 		// Dashboard statistics (simple version)
 		admin.GET("/dashboard/stats", handlers.GetDashboardStatistics)
@@ -165,6 +301,44 @@ func main() {
 		admin.POST("/games/validate-all", handlers.ValidateAllGames)
 		admin.POST("/games/:id/notify", handlers.SendGameReleaseNotifications)
 		admin.POST("/games/:id/process-images", handlers.ProcessGameImages)
+
+		// Catalog bulk import/export (CSV or JSON)
+		admin.POST("/catalog/import", handlers.ImportCatalog)
+		admin.GET("/catalog/export", handlers.ExportCatalog)
+
+		// Fold categories into another one
+		admin.POST("/categories/merge", handlers.MergeCategories)
+
+		// Store credit
+		admin.POST("/wallets/:userId/entries", handlers.AddWalletEntry)
+
+		// Promo codes
+		admin.GET("/coupons", handlers.GetCoupons)
+		admin.POST("/coupons", handlers.CreateCoupon)
+		admin.GET("/coupons/:id", handlers.GetCoupon)
+		admin.PUT("/coupons/:id", handlers.UpdateCoupon)
+		admin.DELETE("/coupons/:id", handlers.DeleteCoupon)
+		admin.GET("/coupons/:id/redemptions", handlers.GetCouponRedemptions)
+
+		// Refund review queue
+		admin.GET("/refunds", handlers.GetRefundQueue)
+		admin.POST("/refunds/:id/approve", handlers.ApproveRefund)
+		admin.POST("/refunds/:id/reject", handlers.RejectRefund)
+
+		// Sales tax
+		admin.GET("/tax-rules", handlers.GetTaxRules)
+		admin.POST("/tax-rules", handlers.CreateTaxRule)
+		admin.PUT("/tax-rules/:id", handlers.UpdateTaxRule)
+		admin.DELETE("/tax-rules/:id", handlers.DeleteTaxRule)
+		admin.GET("/reports/tax", handlers.GetTaxReport)
+
+		// Developer payouts
+		admin.GET("/payouts", handlers.GetPayoutQueue)
+		admin.POST("/payouts/:id/approve", handlers.ApprovePayout)
+		admin.POST("/payouts/:id/reject", handlers.RejectPayout)
+		admin.GET("/revenue-shares", handlers.GetRevenueShares)
+		admin.PUT("/revenue-shares", handlers.SetRevenueShare)
+		admin.DELETE("/revenue-shares/:id", handlers.DeleteRevenueShare)
 	}
 
 	port := os.Getenv("PORT")
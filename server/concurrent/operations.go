@@ -5,6 +5,7 @@ import (
 	"awesomeProject/models"
 	"context"
 	"fmt"
+	"gorm.io/gorm"
 	"sync"
 	"time"
 )
@@ -37,6 +38,8 @@ type GameDetails struct {
 	Game         models.Game
 	Reviews      []models.Review
 	RelatedGames []models.Game
+	AddOns       []models.Game
+	Series       *models.Series
 	Statistics   GameStatistics
 	Error        error
 }
@@ -61,9 +64,11 @@ func FetchGameWithDetails(gameID uint) (*GameDetails, error) {
 	reviewsChan := make(chan []models.Review, 1)
 	relatedChan := make(chan []models.Game, 1)
 	statsChan := make(chan GameStatistics, 1)
+	addOnsChan := make(chan []models.Game, 1)
+	seriesChan := make(chan *models.Series, 1)
 
 	var wg sync.WaitGroup
-	wg.Add(4)
+	wg.Add(6)
 
 	// Goroutine 1: Загрузка основной информации об игре
 	go func() {
@@ -144,7 +149,7 @@ func FetchGameWithDetails(gameID uint) (*GameDetails, error) {
 		// Количество владельцев
 		go func() {
 			defer statsWg.Done()
-			db.DB.Model(&models.Ownership{}).Where("game_id = ?", gameID).Count(&stats.TotalOwners)
+			db.DB.Model(&models.Ownership{}).Where("game_id = ? AND status = ?", gameID, "owned").Count(&stats.TotalOwners)
 		}()
 
 		// Игры той же категории (после загрузки основной игры)
@@ -166,6 +171,36 @@ func FetchGameWithDetails(gameID uint) (*GameDetails, error) {
 		statsChan <- stats
 	}()
 
+	// Goroutine 5: Загрузка дополнений (DLC, саундтреки, расширения)
+	go func() {
+		defer wg.Done()
+		var addOns []models.Game
+		db.DB.Where("base_game_id = ?", gameID).
+			Order("id").
+			Find(&addOns)
+		addOnsChan <- addOns
+	}()
+
+	// Goroutine 6: Загрузка серии с остальными играми франшизы по порядку
+	go func() {
+		defer wg.Done()
+		var entry models.SeriesEntry
+		if err := db.DB.Where("game_id = ?", gameID).First(&entry).Error; err != nil {
+			seriesChan <- nil
+			return
+		}
+
+		var series models.Series
+		err := db.DB.Preload("Entries", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("position")
+		}).Preload("Entries.Game").First(&series, entry.SeriesID).Error
+		if err != nil {
+			seriesChan <- nil
+			return
+		}
+		seriesChan <- &series
+	}()
+
 	// Ждем завершения всех goroutines
 	go func() {
 		wg.Wait()
@@ -173,6 +208,8 @@ func FetchGameWithDetails(gameID uint) (*GameDetails, error) {
 		close(reviewsChan)
 		close(relatedChan)
 		close(statsChan)
+		close(addOnsChan)
+		close(seriesChan)
 	}()
 
 	// Собираем результаты с таймаутом
@@ -188,6 +225,8 @@ func FetchGameWithDetails(gameID uint) (*GameDetails, error) {
 	result.Reviews = <-reviewsChan
 	result.RelatedGames = <-relatedChan
 	result.Statistics = <-statsChan
+	result.AddOns = <-addOnsChan
+	result.Series = <-seriesChan
 
 	return result, nil
 }
@@ -438,7 +477,7 @@ func CalculateDashboardStats() (*DashboardStats, error) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := db.DB.Model(&models.Ownership{}).Count(&stats.TotalSales).Error; err != nil {
+		if err := db.DB.Model(&models.Ownership{}).Where("status = ?", "owned").Count(&stats.TotalSales).Error; err != nil {
 			errChan <- fmt.Errorf("sales count: %w", err)
 		}
 	}()
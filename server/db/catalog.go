@@ -0,0 +1,14 @@
+package db
+
+import "log"
+
+// backfillExternalIDs gives external IDs to games and categories created
+// before they were assigned on create, so catalog exports round-trip
+func backfillExternalIDs() {
+	if err := DB.Exec("UPDATE categories SET external_id = 'category-' || id WHERE external_id IS NULL").Error; err != nil {
+		log.Println("failed to backfill category external IDs:", err)
+	}
+	if err := DB.Exec("UPDATE games SET external_id = 'game-' || id WHERE external_id IS NULL").Error; err != nil {
+		log.Println("failed to backfill game external IDs:", err)
+	}
+}
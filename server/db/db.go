@@ -28,10 +28,27 @@ func InitDB() {
 		log.Fatal("failed to connect to the database:", openErr)
 	}
 
-	migrateErr := DB.AutoMigrate(&models.User{}, &models.Game{}, &models.Ownership{}, &models.Category{}, &models.Review{})
+	Migrate()
+	log.Println("Database connected and migrated")
+}
+
+// Migrate brings the schema of DB up to date and backfills derived columns
+func Migrate() {
+	// Data fixes the schema changes below depend on
+	runOneOffMigrations()
+
+	migrateErr := DB.AutoMigrate(&models.User{}, &models.Game{}, &models.Ownership{}, &models.Category{}, &models.Review{}, &models.GamePlatform{}, &models.Bundle{}, &models.Organization{}, &models.OrganizationMember{}, &models.Series{}, &models.SeriesEntry{}, &models.SlugRedirect{}, &models.GameRevision{}, &models.Order{}, &models.OrderItem{}, &models.Cart{}, &models.CartItem{}, &models.Wallet{}, &models.WalletEntry{}, &models.RefundRequest{}, &models.Gift{}, &models.KeyBatch{}, &models.ActivationKey{}, &models.Coupon{}, &models.CouponRedemption{}, &models.Receipt{}, &models.TaxRule{}, &models.RevenueShare{}, &models.EarningEntry{}, &models.PayoutRequest{}, &models.Notification{}, &models.FamilyGroup{}, &models.FamilyMember{}, &models.PlaySession{})
 	if migrateErr != nil {
 		log.Fatal("failed to migrate:", migrateErr)
 	}
 
-	log.Println("Database connected and migrated")
+	// Receipt numbers come from a sequence so they never repeat
+	if err := DB.Exec("CREATE SEQUENCE IF NOT EXISTS receipt_number_seq").Error; err != nil {
+		log.Fatal("failed to create receipt number sequence:", err)
+	}
+
+	backfillSlugs("games", models.SlugEntityGame)
+	backfillSlugs("categories", models.SlugEntityCategory)
+	backfillExternalIDs()
+	backfillReviewDates()
 }
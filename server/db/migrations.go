@@ -0,0 +1,94 @@
+package db
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// oneOffMigration fixes up existing data once, before AutoMigrate. run
+// returns how many rows it touched.
+type oneOffMigration struct {
+	name string
+	run  func(tx *gorm.DB) (int64, error)
+}
+
+// oneOffMigrations run in order; each is recorded in schema_migrations so
+// it never runs again
+var oneOffMigrations = []oneOffMigration{
+	{name: "dedupe_ownerships", run: dedupeOwnerships},
+	{name: "dedupe_reviews", run: dedupeReviews},
+	{name: "drop_orphan_cart_items", run: dropOrphanCartItems},
+}
+
+// runOneOffMigrations applies the migrations that haven't run yet
+func runOneOffMigrations() {
+	err := DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`).Error
+	if err != nil {
+		log.Fatal("failed to create schema_migrations:", err)
+	}
+
+	for _, migration := range oneOffMigrations {
+		var applied int64
+		if err := DB.Table("schema_migrations").Where("name = ?", migration.name).Count(&applied).Error; err != nil {
+			log.Fatal("failed to read schema_migrations:", err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		var rows int64
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if rows, err = migration.run(tx); err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO schema_migrations (name) VALUES (?)", migration.name).Error
+		})
+		if err != nil {
+			log.Fatalf("migration %s failed: %v", migration.name, err)
+		}
+		log.Printf("migration %s applied, %d rows affected", migration.name, rows)
+	}
+}
+
+// dedupeOwnerships keeps one ownership per user and game, preferring an
+// owned one, so the unique index on ownerships can be created. The others
+// are kept in ownerships_archive.
+func dedupeOwnerships(tx *gorm.DB) (int64, error) {
+	if !tx.Migrator().HasTable("ownerships") {
+		return 0, nil
+	}
+	return archiveRows(tx, "ownerships", `SELECT id FROM (
+		SELECT id, ROW_NUMBER() OVER (
+			PARTITION BY user_id, game_id ORDER BY (status = 'owned') DESC, id DESC
+		) AS rank FROM ownerships
+	) ranked WHERE rank > 1`)
+}
+
+// dropOrphanCartItems removes cart items of games deleted before deleting
+// a game cleared it from carts; such carts could never be checked out
+func dropOrphanCartItems(tx *gorm.DB) (int64, error) {
+	if !tx.Migrator().HasTable("cart_items") {
+		return 0, nil
+	}
+	result := tx.Exec("DELETE FROM cart_items WHERE NOT EXISTS (SELECT 1 FROM games WHERE games.id = cart_items.game_id)")
+	return result.RowsAffected, result.Error
+}
+
+// archiveRows moves the rows of table whose id is in the ids subquery to
+// <table>_archive, creating it with the table's columns when needed
+func archiveRows(tx *gorm.DB, table, ids string) (int64, error) {
+	archive := table + "_archive"
+	if err := tx.Exec("CREATE TABLE IF NOT EXISTS " + archive + " AS TABLE " + table + " WITH NO DATA").Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Exec("INSERT INTO " + archive + " SELECT * FROM " + table + " WHERE id IN (" + ids + ")").Error; err != nil {
+		return 0, err
+	}
+	result := tx.Exec("DELETE FROM " + table + " WHERE id IN (" + ids + ")")
+	return result.RowsAffected, result.Error
+}
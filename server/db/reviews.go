@@ -0,0 +1,26 @@
+package db
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// dedupeReviews keeps only the latest review per user and game, so the
+// unique index on reviews can be created over data from before it existed.
+// The older reviews are kept in reviews_archive.
+func dedupeReviews(tx *gorm.DB) (int64, error) {
+	if !tx.Migrator().HasTable("reviews") {
+		return 0, nil
+	}
+	return archiveRows(tx, "reviews", `SELECT older.id FROM reviews older JOIN reviews newer
+		ON older.user_id = newer.user_id AND older.game_id = newer.game_id AND older.id < newer.id`)
+}
+
+// backfillReviewDates stamps reviews written before they had timestamps
+func backfillReviewDates() {
+	err := DB.Exec("UPDATE reviews SET created_at = NOW(), updated_at = NOW() WHERE created_at IS NULL").Error
+	if err != nil {
+		log.Println("failed to backfill review dates:", err)
+	}
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"awesomeProject/utils"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// UniqueSlug returns base, or base with a numeric suffix, that no other row
+// of the table uses. id is the row being slugged (0 for a new row).
+func UniqueSlug(tx *gorm.DB, table, base string, id uint) (string, error) {
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Table(table).Where("slug = ? AND id <> ?", slug, id).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// backfillSlugs gives slugs to rows created before slugs existed, and new
+// ones to rows whose slug is only digits
+func backfillSlugs(table, fallback string) {
+	type row struct {
+		ID   uint
+		Name string
+	}
+	var rows []row
+	// All-digit slugs read as IDs, so those rows get a new one as well
+	if err := DB.Table(table).Select("id, name").Where("slug IS NULL OR slug = '' OR slug ~ '^[0-9]+$'").Find(&rows).Error; err != nil {
+		log.Println("failed to load rows without slug:", err)
+		return
+	}
+
+	for _, r := range rows {
+		base := utils.Slugify(r.Name)
+		switch {
+		case base == "":
+			base = fmt.Sprintf("%s-%d", fallback, r.ID)
+		case utils.IsNumericSlug(base):
+			base += "-" + fallback
+		}
+		slug, err := UniqueSlug(DB, table, base, r.ID)
+		if err == nil {
+			err = DB.Table(table).Where("id = ?", r.ID).Update("slug", slug).Error
+		}
+		if err != nil {
+			log.Printf("failed to backfill slug for %s %d: %v", table, r.ID, err)
+		}
+	}
+}
@@ -22,7 +22,7 @@ func Login(c *gin.Context) {
 			"ip":    c.ClientIP(),
 		})
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.BadRequest(c, err)
 		return
 	}
 
@@ -39,17 +39,17 @@ func Login(c *gin.Context) {
 			"error": err.Error(),
 		})
 
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный email или пароль"})
+		utils.ErrorResponse(c, http.StatusUnauthorized, utils.MsgInvalidCredentials)
 		return
 	}
 
 	if user.IsBanned {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Your account is banned"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccountBanned)
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный email или пароль"})
+		utils.ErrorResponse(c, http.StatusUnauthorized, utils.MsgInvalidCredentials)
 		return
 	}
 
@@ -65,53 +65,88 @@ func Login(c *gin.Context) {
 	}
 	tokenString, err := token.SignedString([]byte(secret))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания токена"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgTokenCreateFailed)
 		return
 	}
 
+	// Carry over the cart collected before logging in
+	if cartToken := c.GetHeader(CartTokenHeader); cartToken != "" {
+		if err := mergeAnonymousCart(user, cartToken); err != nil {
+			utils.LogWarn("Failed to merge anonymous cart", map[string]interface{}{
+				"user_id": user.ID,
+				"error":   err.Error(),
+			})
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"token": tokenString,
 		"user":  user,
 	})
 }
 
+// authenticate resolves the user from the Bearer token of the request
+func authenticate(c *gin.Context) (models.User, utils.MessageCode) {
+	var user models.User
+
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || len(authHeader) < 7 || authHeader[:7] != "Bearer " {
+		return user, utils.MsgUnauthorized
+	}
+	tokenString := authHeader[7:]
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "your_default_secret_key"
+	}
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(secret), nil
+	})
+	if err != nil || !token.Valid {
+		return user, utils.MsgInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return user, utils.MsgInvalidTokenClaims
+	}
+
+	userID := uint(claims["user_id"].(float64))
+	if err := db.DB.First(&user, userID).Error; err != nil {
+		return user, utils.MsgUserNotFound
+	}
+	return user, ""
+}
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" || len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Неавторизован"})
+		user, code := authenticate(c)
+		if code != "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, code)
 			c.Abort()
 			return
 		}
-		tokenString := authHeader[7:]
 
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "your_default_secret_key"
-		}
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный токен"})
-			c.Abort()
-			return
-		}
+		c.Set("user", user)
+		c.Next()
+	}
+}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительные данные токена"})
-			c.Abort()
+// OptionalAuthMiddleware sets the user when the request carries a token and
+// lets anonymous requests through. Invalid tokens are still rejected.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
 			return
 		}
 
-		userID := uint(claims["user_id"].(float64))
-		var user models.User
-		if err := db.DB.First(&user, userID).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не найден"})
+		user, code := authenticate(c)
+		if code != "" {
+			utils.ErrorResponse(c, http.StatusUnauthorized, code)
 			c.Abort()
 			return
 		}
@@ -120,3 +155,13 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// currentUser returns the authenticated user, if any
+func currentUser(c *gin.Context) (models.User, bool) {
+	value, ok := c.Get("user")
+	if !ok {
+		return models.User{}, false
+	}
+	user, ok := value.(models.User)
+	return user, ok
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"math"
+	"net/http"
+)
+
+// bundleQuote - bundle price for a specific buyer
+type bundleQuote struct {
+	FullPrice    float64       `json:"fullPrice"`
+	Price        float64       `json:"price"`
+	MissingGames []models.Game `json:"missingGames"`
+	OwnedGameIDs []uint        `json:"ownedGameIds"`
+}
+
+// quoteBundle computes "complete the set" pricing: the bundle price is
+// scaled by the share of the members' list price the buyer still misses
+func quoteBundle(bundle models.Bundle, owned map[uint]bool) bundleQuote {
+	quote := bundleQuote{
+		FullPrice:    bundle.Price,
+		MissingGames: []models.Game{},
+		OwnedGameIDs: []uint{},
+	}
+
+	var listTotal, missingTotal float64
+	for _, game := range bundle.Games {
+		listTotal += game.Price
+		if owned[game.ID] {
+			quote.OwnedGameIDs = append(quote.OwnedGameIDs, game.ID)
+			continue
+		}
+		missingTotal += game.Price
+		quote.MissingGames = append(quote.MissingGames, game)
+	}
+
+	switch {
+	case len(quote.MissingGames) == 0:
+		quote.Price = 0
+	case listTotal == 0 || len(quote.OwnedGameIDs) == 0:
+		quote.Price = bundle.Price
+	default:
+		quote.Price = math.Round(bundle.Price*missingTotal/listTotal*100) / 100
+	}
+
+	return quote
+}
+
+// bundleGameIDs returns the IDs of the bundle's games
+func bundleGameIDs(bundle models.Bundle) []uint {
+	ids := make([]uint, len(bundle.Games))
+	for i, game := range bundle.Games {
+		ids[i] = game.ID
+	}
+	return ids
+}
+
+// GetBundles - list all bundles
+func GetBundles(c *gin.Context) {
+	var bundles []models.Bundle
+	if err := db.DB.Preload("Games").Find(&bundles).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchBundlesFailed)
+		return
+	}
+	c.JSON(http.StatusOK, bundles)
+}
+
+// GetBundleByID - bundle with its games
+func GetBundleByID(c *gin.Context) {
+	var bundle models.Bundle
+	if err := db.DB.Preload("Games").First(&bundle, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgBundleNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, bundle)
+}
+
+// GetBundlePrice - bundle price for the current user
+// GET /bundles/:id/price
+func GetBundlePrice(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var bundle models.Bundle
+	if err := db.DB.Preload("Games").First(&bundle, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgBundleNotFound)
+		return
+	}
+
+	owned := ownedGameIDs(db.DB, user.ID, bundleGameIDs(bundle))
+	c.JSON(http.StatusOK, gin.H{
+		"bundle": bundle,
+		"quote":  quoteBundle(bundle, owned),
+	})
+}
+
+// CreateBundle - admins, or developers bundling their own games
+func CreateBundle(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" && user.Role != "developer" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOrDevelopers)
+		return
+	}
+
+	var input models.BundleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var games []models.Game
+	if err := db.DB.Where("id IN ?", input.GameIDs).Find(&games).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGamesFailed)
+		return
+	}
+	if len(games) != len(uniqueIDs(input.GameIDs)) {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgGameNotFound)
+		return
+	}
+	for _, game := range games {
+		if !canManageGame(user, game) {
+			utils.ErrorResponse(c, http.StatusForbidden, utils.MsgOwnGamesOnly)
+			return
+		}
+	}
+
+	bundle := models.Bundle{
+		Name:        input.Name,
+		Description: input.Description,
+		Price:       input.Price,
+		CreatedByID: user.ID,
+		Games:       games,
+	}
+	if err := db.DB.Create(&bundle).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCreateBundleFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+// DeleteBundle - admins or the bundle's creator
+func DeleteBundle(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var bundle models.Bundle
+	if err := db.DB.First(&bundle, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgBundleNotFound)
+		return
+	}
+	if user.Role != "admin" && user.ID != bundle.CreatedByID {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&bundle).Association("Games").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&bundle).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgDeleteBundleFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bundle deleted"})
+}
+
+// bundleOrderItems splits the quoted bundle price across the missing games
+// in proportion to their list prices
+func bundleOrderItems(quote bundleQuote) []models.OrderItem {
+	items := orderItemsFor(quote.MissingGames)
+
+	var listTotal float64
+	for _, game := range quote.MissingGames {
+		listTotal += game.Price
+	}
+
+	remaining := quote.Price
+	for i := range items {
+		if i == len(items)-1 {
+			items[i].Price = math.Round(remaining*100) / 100
+			break
+		}
+		share := quote.Price / float64(len(items))
+		if listTotal > 0 {
+			share = quote.Price * items[i].ListPrice / listTotal
+		}
+		items[i].Price = math.Round(share*100) / 100
+		remaining -= items[i].Price
+	}
+	return items
+}
+
+// PurchaseBundle orders every bundle game the user doesn't own yet
+// POST /bundles/:id/purchase
+func PurchaseBundle(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	input, ok := bindCheckoutInput(c)
+	if !ok {
+		return
+	}
+	provider, ok := paymentProvider(c, input.PaymentMethod)
+	if !ok {
+		return
+	}
+
+	var bundle models.Bundle
+	if err := db.DB.Preload("Games").First(&bundle, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgBundleNotFound)
+		return
+	}
+
+	gameIDs := bundleGameIDs(bundle)
+	owned := ownedGameIDs(db.DB, user.ID, gameIDs)
+	quote := quoteBundle(bundle, owned)
+	if len(quote.MissingGames) == 0 {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgBundleAlreadyOwned)
+		return
+	}
+
+	// Add-ons need their base game, either owned already or part of the bundle
+	inBundle := make(map[uint]bool)
+	for _, id := range gameIDs {
+		inBundle[id] = true
+	}
+	for _, game := range quote.MissingGames {
+		if game.IsAddOn() && !owned[*game.BaseGameID] && !inBundle[*game.BaseGameID] {
+			utils.ErrorResponse(c, http.StatusForbidden, utils.MsgBaseGameNotOwned)
+			return
+		}
+	}
+
+	if len(pendingOrderGameIDs(db.DB, user.ID, gameIDs)) > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgPaymentPending)
+		return
+	}
+
+	order, payment, err := checkout(user, provider, models.Order{Items: bundleOrderItems(quote), CouponCode: input.CouponCode}, nil)
+	checkoutResponse(c, order, payment, err, gin.H{
+		"message": "Bundle purchased",
+		"price":   quote.Price,
+		"games":   quote.MissingGames,
+	})
+}
+
+// uniqueIDs removes duplicate IDs keeping the original order
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
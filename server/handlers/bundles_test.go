@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"awesomeProject/models"
+	"reflect"
+	"testing"
+)
+
+func TestQuoteBundle(t *testing.T) {
+	bundle := models.Bundle{
+		Price: 40,
+		Games: []models.Game{
+			{ID: 1, Price: 30},
+			{ID: 2, Price: 20},
+			{ID: 3, Price: 10},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		bundle  models.Bundle
+		owned   map[uint]bool
+		price   float64
+		missing []uint
+		ownedID []uint
+	}{
+		{
+			name:    "nothing owned",
+			bundle:  bundle,
+			price:   40,
+			missing: []uint{1, 2, 3},
+			ownedID: []uint{},
+		},
+		{
+			name:    "scaled by the missing list price",
+			bundle:  bundle,
+			owned:   map[uint]bool{1: true},
+			price:   20,
+			missing: []uint{2, 3},
+			ownedID: []uint{1},
+		},
+		{
+			name:    "rounded to cents",
+			bundle:  bundle,
+			owned:   map[uint]bool{2: true, 3: true},
+			price:   20,
+			missing: []uint{1},
+			ownedID: []uint{2, 3},
+		},
+		{
+			name:    "uneven share",
+			bundle:  models.Bundle{Price: 9.99, Games: []models.Game{{ID: 1, Price: 10}, {ID: 2, Price: 20}}},
+			owned:   map[uint]bool{1: true},
+			price:   6.66,
+			missing: []uint{2},
+			ownedID: []uint{1},
+		},
+		{
+			name:    "everything owned",
+			bundle:  bundle,
+			owned:   map[uint]bool{1: true, 2: true, 3: true},
+			price:   0,
+			missing: []uint{},
+			ownedID: []uint{1, 2, 3},
+		},
+		{
+			name:    "free members keep the bundle price",
+			bundle:  models.Bundle{Price: 5, Games: []models.Game{{ID: 1}, {ID: 2}}},
+			owned:   map[uint]bool{1: true},
+			price:   5,
+			missing: []uint{2},
+			ownedID: []uint{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := quoteBundle(tt.bundle, tt.owned)
+			if quote.FullPrice != tt.bundle.Price {
+				t.Errorf("fullPrice = %v, want %v", quote.FullPrice, tt.bundle.Price)
+			}
+			if quote.Price != tt.price {
+				t.Errorf("price = %v, want %v", quote.Price, tt.price)
+			}
+			missing := make([]uint, len(quote.MissingGames))
+			for i, game := range quote.MissingGames {
+				missing[i] = game.ID
+			}
+			if !reflect.DeepEqual(missing, tt.missing) {
+				t.Errorf("missing = %v, want %v", missing, tt.missing)
+			}
+			if !reflect.DeepEqual(quote.OwnedGameIDs, tt.ownedID) {
+				t.Errorf("owned = %v, want %v", quote.OwnedGameIDs, tt.ownedID)
+			}
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// CartTokenHeader identifies an anonymous cart
+const CartTokenHeader = "X-Cart-Token"
+
+var errCartChanged = errors.New("cart changed during checkout")
+
+// cartLine - a cart game with its current price. Owned games stay listed
+// but aren't charged; they leave the cart on the next add or checkout.
+type cartLine struct {
+	Game            models.Game `json:"game"`
+	ListPrice       float64     `json:"listPrice"`
+	Price           float64     `json:"price"`
+	DiscountPercent int         `json:"discountPercent,omitempty"`
+	Owned           bool        `json:"owned,omitempty"`
+	AddedAt         time.Time   `json:"addedAt"`
+}
+
+// cartView - priced cart contents
+type cartView struct {
+	Token    string     `json:"token,omitempty"`
+	Items    []cartLine `json:"items"`
+	Subtotal float64    `json:"subtotal"`
+	Discount float64    `json:"discount"`
+	Total    float64    `json:"total"`
+}
+
+// newCartToken returns a random token for an anonymous cart
+func newCartToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// loadCart returns the caller's cart: the user's one for authenticated
+// requests, otherwise the anonymous cart named by CartTokenHeader.
+// With create set, a missing cart is created.
+func loadCart(c *gin.Context, create bool) (models.Cart, error) {
+	var cart models.Cart
+
+	if user, ok := currentUser(c); ok {
+		if create {
+			err := db.DB.Where(models.Cart{UserID: &user.ID}).FirstOrCreate(&cart).Error
+			return cart, err
+		}
+		err := db.DB.Where("user_id = ?", user.ID).First(&cart).Error
+		return cart, err
+	}
+
+	if token := c.GetHeader(CartTokenHeader); token != "" {
+		err := db.DB.Where("token = ? AND user_id IS NULL", token).First(&cart).Error
+		if !errors.Is(err, gorm.ErrRecordNotFound) || !create {
+			return cart, err
+		}
+	} else if !create {
+		return cart, gorm.ErrRecordNotFound
+	}
+
+	token, err := newCartToken()
+	if err != nil {
+		return cart, err
+	}
+	cart = models.Cart{Token: &token}
+	return cart, db.DB.Create(&cart).Error
+}
+
+// pruneOwnedCartItems drops the games the user has come to own from the cart
+func pruneOwnedCartItems(tx *gorm.DB, cartID, userID uint) error {
+	owned := tx.Model(&models.Ownership{}).Select("game_id").Where("user_id = ? AND status = ?", userID, "owned")
+	return tx.Where("cart_id = ? AND game_id IN (?)", cartID, owned).Delete(&models.CartItem{}).Error
+}
+
+// buildCartView prices the cart with active discounts. Games the user
+// already owns are flagged and left out of the totals.
+func buildCartView(cart models.Cart, userID uint) (cartView, error) {
+	view := cartView{Items: []cartLine{}}
+	if cart.Token != nil {
+		view.Token = *cart.Token
+	}
+	if cart.ID == 0 {
+		return view, nil
+	}
+
+	var items []models.CartItem
+	if err := db.DB.Where("cart_id = ?", cart.ID).Preload("Game").Order("id").Find(&items).Error; err != nil {
+		return view, err
+	}
+
+	owned := map[uint]bool{}
+	if userID != 0 {
+		gameIDs := make([]uint, len(items))
+		for i, item := range items {
+			gameIDs[i] = item.GameID
+		}
+		owned = ownedGameIDs(db.DB, userID, gameIDs)
+	}
+
+	now := time.Now()
+	for _, item := range items {
+		line := cartLine{
+			Game:      item.Game,
+			ListPrice: item.Game.Price,
+			Price:     item.Game.EffectivePrice(now),
+			Owned:     owned[item.GameID],
+			AddedAt:   item.CreatedAt,
+		}
+		if item.Game.DiscountActive(now) {
+			line.DiscountPercent = item.Game.DiscountPercent
+		}
+		view.Items = append(view.Items, line)
+		if line.Owned {
+			continue
+		}
+		view.Subtotal += line.ListPrice
+		view.Total += line.Price
+	}
+
+	view.Subtotal = math.Round(view.Subtotal*100) / 100
+	view.Total = math.Round(view.Total*100) / 100
+	view.Discount = math.Round((view.Subtotal-view.Total)*100) / 100
+	return view, nil
+}
+
+// cartResponse sends the priced cart
+func cartResponse(c *gin.Context, cart models.Cart) {
+	user, _ := currentUser(c)
+	view, err := buildCartView(cart, user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+	if view.Token != "" {
+		c.Header(CartTokenHeader, view.Token)
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+// GetCart - the caller's cart with current prices
+func GetCart(c *gin.Context) {
+	cart, err := loadCart(c, false)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+	cartResponse(c, cart)
+}
+
+// AddCartItem - add a game to the cart. Anonymous callers get a cart token
+// in the response to send back in the X-Cart-Token header.
+// POST /cart/items
+func AddCartItem(c *gin.Context) {
+	var input models.CartItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var game models.Game
+	if err := db.DB.First(&game, input.GameID).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
+		return
+	}
+	if user, ok := currentUser(c); ok && ownedGameIDs(db.DB, user.ID, []uint{game.ID})[game.ID] {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGameAlreadyOwned)
+		return
+	}
+
+	cart, err := loadCart(c, true)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+
+	item := models.CartItem{CartID: cart.ID, GameID: game.ID}
+	if err := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&item).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+	if cart.UserID != nil {
+		if err := pruneOwnedCartItems(db.DB, cart.ID, *cart.UserID); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+			return
+		}
+	}
+
+	cartResponse(c, cart)
+}
+
+// RemoveCartItem - remove a game from the cart
+// DELETE /cart/items/:gameId
+func RemoveCartItem(c *gin.Context) {
+	cart, err := loadCart(c, false)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCartEmpty)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+
+	if err := db.DB.Where("cart_id = ? AND game_id = ?", cart.ID, c.Param("gameId")).Delete(&models.CartItem{}).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+
+	cartResponse(c, cart)
+}
+
+// ClearCart - remove every game from the cart
+// DELETE /cart
+func ClearCart(c *gin.Context) {
+	cart, err := loadCart(c, false)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+	if cart.ID != 0 {
+		if err := db.DB.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+			return
+		}
+	}
+
+	cartResponse(c, cart)
+}
+
+// CheckoutCart turns the whole cart into one order. Games the user owns by
+// now are dropped first. The cart is emptied in the same transaction that
+// creates the order; a failed payment puts the games back.
+// POST /cart/checkout
+func CheckoutCart(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	input, ok := bindCheckoutInput(c)
+	if !ok {
+		return
+	}
+	provider, ok := paymentProvider(c, input.PaymentMethod)
+	if !ok {
+		return
+	}
+
+	cart, err := loadCart(c, false)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+	if cart.ID != 0 {
+		if err := pruneOwnedCartItems(db.DB, cart.ID, user.ID); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+			return
+		}
+	}
+	view, err := buildCartView(cart, user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCartFailed)
+		return
+	}
+
+	var games []models.Game
+	var gameIDs []uint
+	inCart := make(map[uint]bool)
+	for _, line := range view.Items {
+		if line.Owned {
+			continue
+		}
+		games = append(games, line.Game)
+		gameIDs = append(gameIDs, line.Game.ID)
+		inCart[line.Game.ID] = true
+	}
+	if len(games) == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCartEmpty)
+		return
+	}
+
+	// Add-ons need their base game, either owned already or in the cart
+	var baseIDs []uint
+	for _, game := range games {
+		if game.IsAddOn() {
+			baseIDs = append(baseIDs, *game.BaseGameID)
+		}
+	}
+	owned := ownedGameIDs(db.DB, user.ID, baseIDs)
+	for _, game := range games {
+		if game.IsAddOn() && !owned[*game.BaseGameID] && !inCart[*game.BaseGameID] {
+			utils.ErrorResponse(c, http.StatusForbidden, utils.MsgBaseGameNotOwned)
+			return
+		}
+	}
+
+	if len(pendingOrderGameIDs(db.DB, user.ID, gameIDs)) > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgPaymentPending)
+		return
+	}
+
+	order, payment, err := checkout(user, provider, models.Order{Items: orderItemsFor(games), CouponCode: input.CouponCode}, func(tx *gorm.DB) error {
+		// Lock the cart, then make sure it still holds what was priced
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Cart{}, cart.ID).Error; err != nil {
+			return err
+		}
+		var current []uint
+		if err := tx.Model(&models.CartItem{}).Where("cart_id = ?", cart.ID).Pluck("game_id", &current).Error; err != nil {
+			return err
+		}
+		if !sameIDSet(current, gameIDs) {
+			return errCartChanged
+		}
+		return tx.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error
+	})
+	if errors.Is(err, errCartChanged) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgCartChanged)
+		return
+	}
+
+	if order.Status == models.OrderStatusFailed {
+		for _, gameID := range gameIDs {
+			item := models.CartItem{CartID: cart.ID, GameID: gameID}
+			if err := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&item).Error; err != nil {
+				utils.Log.Error(fmt.Sprintf("Failed to restore cart %d after failed order %d: %v", cart.ID, order.ID, err))
+				break
+			}
+		}
+	}
+
+	checkoutResponse(c, order, payment, err, gin.H{"message": "Order placed"})
+}
+
+// mergeAnonymousCart moves the games of an anonymous cart into the user's
+// cart, skipping games the user owns, and deletes the anonymous cart
+func mergeAnonymousCart(user models.User, token string) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var anonymous models.Cart
+		err := tx.Where("token = ? AND user_id IS NULL", token).Preload("Items").First(&anonymous).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var cart models.Cart
+		if err := tx.Where(models.Cart{UserID: &user.ID}).FirstOrCreate(&cart).Error; err != nil {
+			return err
+		}
+
+		gameIDs := make([]uint, len(anonymous.Items))
+		for i, item := range anonymous.Items {
+			gameIDs[i] = item.GameID
+		}
+		owned := ownedGameIDs(tx, user.ID, gameIDs)
+
+		for _, gameID := range gameIDs {
+			if owned[gameID] {
+				continue
+			}
+			item := models.CartItem{CartID: cart.ID, GameID: gameID}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&item).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("cart_id = ?", anonymous.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&anonymous).Error
+	})
+}
+
+// sameIDSet reports whether both lists hold the same IDs
+func sameIDSet(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]uint(nil), a...)
+	b = append([]uint(nil), b...)
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
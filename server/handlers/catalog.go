@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// catalogFormat returns "csv" or "json" from the format query, upload name or content type
+func catalogFormat(c *gin.Context, filename string) string {
+	if format := strings.ToLower(c.Query("format")); format != "" {
+		return format
+	}
+	if ext := strings.ToLower(filepath.Ext(filename)); ext != "" {
+		return strings.TrimPrefix(ext, ".")
+	}
+	if strings.Contains(c.ContentType(), "csv") {
+		return "csv"
+	}
+	return "json"
+}
+
+// parseCatalogCSV reads catalog rows; rows that can't be parsed get errors instead
+func parseCatalogCSV(r io.Reader, lang string) ([]models.CatalogRow, map[int][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+
+	var rows []models.CatalogRow
+	rowErrors := make(map[int][]string)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// Keep going, the line is reported like any other invalid row
+			index := len(rows)
+			rowErrors[index] = append(rowErrors[index],
+				utils.Translate(lang, utils.MsgCatalogMalformedRow)+": "+parseErr.Err.Error())
+			rows = append(rows, models.CatalogRow{})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		index := len(rows)
+		row := models.CatalogRow{
+			ExternalID:         value("external_id"),
+			Name:               value("name"),
+			Description:        value("description"),
+			CategoryExternalID: value("category_external_id"),
+			CategoryName:       value("category_name"),
+			Image:              value("image"),
+		}
+		if raw := value("price"); raw != "" {
+			price, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				rowErrors[index] = append(rowErrors[index], utils.Translate(lang, utils.MsgInvalidPrice))
+			}
+			row.Price = price
+		}
+		if raw := value("developer_id"); raw != "" {
+			developerID, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				rowErrors[index] = append(rowErrors[index], utils.Translate(lang, utils.MsgInvalidDeveloperID))
+			}
+			row.DeveloperID = uint(developerID)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// catalogImageExists checks an image reference: an http(s) URL or an existing upload
+func catalogImageExists(image string) bool {
+	if image == "" || strings.HasPrefix(image, "http://") || strings.HasPrefix(image, "https://") {
+		return true
+	}
+	clean := filepath.Clean(image)
+	if !strings.HasPrefix(clean, "uploads"+string(filepath.Separator)) {
+		return false
+	}
+	_, err := os.Stat(clean)
+	return err == nil
+}
+
+// validateCatalogRows checks every row and returns the per-row report.
+// categories maps known category external IDs to their IDs (0 for ones the import creates).
+func validateCatalogRows(rows []models.CatalogRow, parseErrors map[int][]string, lang string) ([]models.CatalogRowResult, map[string]uint, bool) {
+	trans := utils.ValidationTranslator(lang)
+
+	// Existing games, categories and developers referenced by the file
+	var externalIDs, categoryIDs []string
+	var developerIDs []uint
+	for _, row := range rows {
+		externalIDs = append(externalIDs, row.ExternalID)
+		categoryIDs = append(categoryIDs, row.CategoryExternalID)
+		developerIDs = append(developerIDs, row.DeveloperID)
+	}
+
+	existingGames := make(map[string]bool)
+	var gameExternalIDs []string
+	db.DB.Model(&models.Game{}).Where("external_id IN ?", externalIDs).Pluck("external_id", &gameExternalIDs)
+	for _, id := range gameExternalIDs {
+		existingGames[id] = true
+	}
+
+	categories := make(map[string]uint)
+	var existingCategories []models.Category
+	db.DB.Where("external_id IN ?", categoryIDs).Find(&existingCategories)
+	for _, category := range existingCategories {
+		categories[*category.ExternalID] = category.ID
+	}
+
+	developers := make(map[uint]bool)
+	var existingDevelopers []uint
+	db.DB.Model(&models.User{}).Where("id IN ?", uniqueIDs(developerIDs)).Pluck("id", &existingDevelopers)
+	for _, id := range existingDevelopers {
+		developers[id] = true
+	}
+
+	results := make([]models.CatalogRowResult, len(rows))
+	seen := make(map[string]bool)
+	valid := true
+
+	for i, row := range rows {
+		result := models.CatalogRowResult{
+			Row:        i + 1,
+			ExternalID: row.ExternalID,
+			Errors:     parseErrors[i],
+		}
+
+		// Lines the CSV reader couldn't split have nothing else to check
+		if row == (models.CatalogRow{}) && len(result.Errors) > 0 {
+			result.Action = "error"
+			valid = false
+			results[i] = result
+			continue
+		}
+
+		if err := utils.ValidateStruct(row); err != nil {
+			var validationErrors validator.ValidationErrors
+			if errors.As(err, &validationErrors) {
+				for _, e := range validationErrors {
+					result.Errors = append(result.Errors, e.Translate(trans))
+				}
+			} else {
+				result.Errors = append(result.Errors, err.Error())
+			}
+		}
+		if row.ExternalID != "" && seen[row.ExternalID] {
+			result.Errors = append(result.Errors, utils.Translate(lang, utils.MsgCatalogDuplicateID))
+		}
+		seen[row.ExternalID] = true
+
+		if row.DeveloperID != 0 && !developers[row.DeveloperID] {
+			result.Errors = append(result.Errors, utils.Translate(lang, utils.MsgCatalogDeveloperNotFound))
+		}
+		if _, ok := categories[row.CategoryExternalID]; !ok && row.CategoryExternalID != "" {
+			if row.CategoryName == "" {
+				result.Errors = append(result.Errors, utils.Translate(lang, utils.MsgCatalogCategoryUnknown))
+			} else {
+				// Created by this import, later rows may reference it without a name
+				categories[row.CategoryExternalID] = 0
+			}
+		}
+		if !catalogImageExists(row.Image) {
+			result.Errors = append(result.Errors, utils.Translate(lang, utils.MsgCatalogImageNotFound))
+		}
+
+		switch {
+		case len(result.Errors) > 0:
+			result.Action = "error"
+			valid = false
+		case existingGames[row.ExternalID]:
+			result.Action = "update"
+		default:
+			result.Action = "create"
+		}
+		results[i] = result
+	}
+
+	return results, categories, valid
+}
+
+// importedGame - a game as it was before the import and as it is after
+type importedGame struct {
+	before models.GameSnapshot
+	game   models.Game
+}
+
+// applyCatalogRows upserts categories and games from validated rows,
+// recording a revision for every game the import changes
+func applyCatalogRows(tx *gorm.DB, editor models.User, rows []models.CatalogRow, categories map[string]uint) ([]importedGame, error) {
+	imported := make([]importedGame, 0, len(rows))
+	for _, row := range rows {
+		categoryExternalID := row.CategoryExternalID
+		if categories[categoryExternalID] == 0 || row.CategoryName != "" {
+			var category models.Category
+			err := tx.Where("external_id = ?", categoryExternalID).First(&category).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			category.ExternalID = &categoryExternalID
+			if row.CategoryName != "" {
+				category.Name = row.CategoryName
+			}
+			if category.Slug == "" {
+				slug, err := pickSlug(tx, models.SlugEntityCategory, category.ID, "", category.Name)
+				if err != nil {
+					return nil, err
+				}
+				category.Slug = slug
+			}
+			if err := tx.Save(&category).Error; err != nil {
+				return nil, err
+			}
+			categories[categoryExternalID] = category.ID
+		}
+
+		externalID := row.ExternalID
+		var game models.Game
+		err := tx.Where("external_id = ?", externalID).First(&game).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		before := models.SnapshotGame(game)
+		game.ExternalID = &externalID
+		game.Name = row.Name
+		game.Description = row.Description
+		game.Price = row.Price
+		game.CategoryID = categories[categoryExternalID]
+		game.DeveloperID = row.DeveloperID
+		if row.Image != "" {
+			game.Image = row.Image
+		}
+		if game.Type == "" {
+			game.Type = models.GameTypeGame
+		}
+		if game.Slug == "" {
+			slug, err := pickSlug(tx, models.SlugEntityGame, game.ID, "", game.Name)
+			if err != nil {
+				return nil, err
+			}
+			game.Slug = slug
+		}
+		if err := tx.Omit("Category", "Platforms", "Organization").Save(&game).Error; err != nil {
+			return nil, err
+		}
+		if err := recordGameRevision(tx, editor, models.RevisionImport, before, game, nil); err != nil {
+			return nil, err
+		}
+		imported = append(imported, importedGame{before: before, game: game})
+	}
+	return imported, nil
+}
+
+// ImportCatalog - bulk upsert of games, categories and prices from CSV or JSON
+// POST /admin/catalog/import?format=csv|json&dryRun=true
+func ImportCatalog(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	lang := utils.Language(c)
+	dryRun := c.Query("dryRun") == "true"
+
+	// Accept a multipart "file" upload or the raw request body
+	var body io.Reader = c.Request.Body
+	filename := ""
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCatalogReadFailed)
+			return
+		}
+		defer f.Close()
+		body = f
+		filename = file.Filename
+	}
+
+	var rows []models.CatalogRow
+	parseErrors := make(map[int][]string)
+	switch catalogFormat(c, filename) {
+	case "csv":
+		var err error
+		rows, parseErrors, err = parseCatalogCSV(body, lang)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCatalogReadFailed)
+			return
+		}
+	case "json":
+		if err := json.NewDecoder(body).Decode(&rows); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCatalogReadFailed)
+			return
+		}
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCatalogInvalidFormat)
+		return
+	}
+
+	results, categories, valid := validateCatalogRows(rows, parseErrors, lang)
+	report := gin.H{
+		"dry_run": dryRun,
+		"total":   len(rows),
+		"rows":    results,
+	}
+	counts := map[string]int{"create": 0, "update": 0, "error": 0}
+	for _, result := range results {
+		counts[result.Action]++
+	}
+	report["created"] = counts["create"]
+	report["updated"] = counts["update"]
+	report["errors"] = counts["error"]
+
+	if !valid {
+		report["error"] = utils.Translate(lang, utils.MsgCatalogInvalidRows)
+		report["code"] = utils.MsgCatalogInvalidRows
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	// All or nothing: the catalog is imported in a single transaction
+	var imported []importedGame
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		imported, err = applyCatalogRows(tx, user, rows, categories)
+		return err
+	})
+	if err != nil {
+		utils.LogError("Catalog import failed", map[string]interface{}{
+			"error": err.Error(),
+			"rows":  len(rows),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCatalogImportFailed)
+		return
+	}
+
+	for _, change := range imported {
+		notifyPriceDrop(change.before, change.game)
+	}
+
+	// Invalidate caches
+	if cache.IsRedisAvailable() {
+		cache.DeletePattern(cache.GameCachePrefix + "*")
+		cache.InvalidateGamesList()
+		cache.InvalidateCategories()
+		cache.InvalidateDashboardStats()
+		utils.Log.Info(fmt.Sprintf("Catalog caches invalidated after importing %d rows", len(rows)))
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportCatalog - dump the catalog in the import format
+// GET /admin/catalog/export?format=csv|json
+func ExportCatalog(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "csv" && format != "json" {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCatalogInvalidFormat)
+		return
+	}
+
+	var games []models.Game
+	if err := db.DB.Preload("Category").Order("id").Find(&games).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCatalogExportFailed)
+		return
+	}
+
+	rows := make([]models.CatalogRow, len(games))
+	for i, game := range games {
+		rows[i] = models.CatalogRow{
+			Name:         game.Name,
+			Description:  game.Description,
+			Price:        game.Price,
+			CategoryName: game.Category.Name,
+			DeveloperID:  game.DeveloperID,
+			Image:        game.Image,
+		}
+		// Same IDs the boot backfill stores for rows that predate them
+		rows[i].ExternalID = models.GameExternalID(game.ID)
+		if game.ExternalID != nil {
+			rows[i].ExternalID = *game.ExternalID
+		}
+		rows[i].CategoryExternalID = models.CategoryExternalID(game.CategoryID)
+		if game.Category.ExternalID != nil {
+			rows[i].CategoryExternalID = *game.Category.ExternalID
+		}
+	}
+
+	filename := fmt.Sprintf("catalog-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		c.JSON(http.StatusOK, rows)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	writer := csv.NewWriter(c.Writer)
+	writer.Write(models.CatalogColumns)
+	for _, row := range rows {
+		writer.Write([]string{
+			row.ExternalID,
+			row.Name,
+			row.Description,
+			strconv.FormatFloat(row.Price, 'f', 2, 64),
+			row.CategoryExternalID,
+			row.CategoryName,
+			strconv.FormatUint(uint64(row.DeveloperID), 10),
+			row.Image,
+		})
+	}
+	writer.Flush()
+}
@@ -5,15 +5,111 @@ import (
 	"awesomeProject/db"
 	"awesomeProject/models"
 	"awesomeProject/utils"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"net/http"
 )
 
+// categorySubtreeIDs returns the category and all its descendants
+func categorySubtreeIDs(tx *gorm.DB, categoryID uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION
+		SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+	) SELECT id FROM subtree`, categoryID).Scan(&ids).Error
+	return ids, err
+}
+
+// categoryAncestorIDs returns the parents of the category up to the root
+func categoryAncestorIDs(tx *gorm.DB, categoryID uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Raw(`WITH RECURSIVE ancestors AS (
+		SELECT parent_id AS id FROM categories WHERE id = ?
+		UNION
+		SELECT c.parent_id FROM categories c JOIN ancestors a ON c.id = a.id
+	) SELECT id FROM ancestors WHERE id IS NOT NULL`, categoryID).Scan(&ids).Error
+	return ids, err
+}
+
+// categoryCacheScope returns the categories whose cached game lists change
+// when the category's subtree changes: the subtree and its ancestors
+func categoryCacheScope(tx *gorm.DB, categoryID uint) []uint {
+	subtree, _ := categorySubtreeIDs(tx, categoryID)
+	ancestors, _ := categoryAncestorIDs(tx, categoryID)
+	return append(subtree, ancestors...)
+}
+
+// buildCategoryTree nests categories under their parents and returns the roots.
+// Categories whose parent is not in the list become roots.
+func buildCategoryTree(categories []models.Category) []models.Category {
+	present := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		present[category.ID] = true
+	}
+
+	children := make(map[uint][]models.Category)
+	roots := []models.Category{}
+	for _, category := range categories {
+		if category.ParentID == nil || !present[*category.ParentID] {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var attach func(nodes []models.Category) []models.Category
+	attach = func(nodes []models.Category) []models.Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+	return attach(roots)
+}
+
+// validateCategoryParent checks that parentID exists and is not the category
+// itself or one of its descendants. categoryID is 0 for a new category.
+func validateCategoryParent(tx *gorm.DB, categoryID uint, parentID *uint) utils.MessageCode {
+	if parentID == nil {
+		return ""
+	}
+
+	var parent models.Category
+	if err := tx.First(&parent, *parentID).Error; err != nil {
+		return utils.MsgParentCategoryNotFound
+	}
+	if categoryID == 0 {
+		return ""
+	}
+
+	subtree, err := categorySubtreeIDs(tx, categoryID)
+	if err != nil {
+		return utils.MsgUpdateCategoryFail
+	}
+	for _, id := range subtree {
+		if id == parent.ID {
+			return utils.MsgCategoryCycle
+		}
+	}
+	return ""
+}
+
 // GetCategories with Redis caching
+// Returns the category tree, or a flat list with ?flat=true
 func GetCategories(c *gin.Context) {
+	flat := c.Query("flat") == "true"
+
 	// Try cache first
 	if cache.IsRedisAvailable() {
-		cachedCategories, err := cache.GetCategories()
+		var cachedCategories interface{}
+		var err error
+		if flat {
+			cachedCategories, err = cache.GetCategories()
+		} else {
+			cachedCategories, err = cache.GetCategoryTree()
+		}
 		if err == nil && cachedCategories != nil {
 			utils.Log.Debug("Cache HIT: categories")
 			c.JSON(http.StatusOK, cachedCategories)
@@ -24,35 +120,87 @@ func GetCategories(c *gin.Context) {
 
 	// Fetch from database
 	var categories []models.Category
-	if err := db.DB.Find(&categories).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+	if err := db.DB.Order("name").Find(&categories).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchCategoriesFail)
+		return
+	}
+
+	if flat {
+		if cache.IsRedisAvailable() {
+			cache.SetCategories(categories)
+		}
+		c.JSON(http.StatusOK, categories)
 		return
 	}
 
-	// Cache the result
+	tree := buildCategoryTree(categories)
 	if cache.IsRedisAvailable() {
-		cache.SetCategories(categories)
+		cache.SetCategoryTree(tree)
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// GetCategoryByID - category with its subcategories, by ID or slug
+func GetCategoryByID(c *gin.Context) {
+	categoryID, _, err := resolveSlugRef(models.SlugEntityCategory, c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCategoryNotFound)
+		return
+	}
+
+	ids, err := categorySubtreeIDs(db.DB, categoryID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchCategoriesFail)
+		return
+	}
+	if len(ids) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCategoryNotFound)
+		return
 	}
 
-	c.JSON(http.StatusOK, categories)
+	var categories []models.Category
+	if err := db.DB.Where("id IN ?", ids).Order("name").Find(&categories).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchCategoriesFail)
+		return
+	}
+
+	// The requested category is the only one whose parent is outside its subtree
+	c.JSON(http.StatusOK, buildCategoryTree(categories)[0])
 }
 
 // CreateCategory with cache invalidation
 func CreateCategory(c *gin.Context) {
 	var category models.Category
 	if err := c.ShouldBindJSON(&category); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.BadRequest(c, err)
 		return
 	}
 
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	if code := validateCategoryParent(db.DB, 0, category.ParentID); code != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, code)
+		return
+	}
+
+	slug, err := pickSlug(db.DB, models.SlugEntityCategory, 0, category.Slug, category.Name)
+	if code := slugErrorCode(err); code != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, code)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCreateCategoryFail)
 		return
 	}
+	category.Slug = slug
+	category.Children = nil
 
 	if err := db.DB.Create(&category).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCreateCategoryFail)
 		return
 	}
 
@@ -66,61 +214,282 @@ func CreateCategory(c *gin.Context) {
 }
 
 // UpdateCategory with cache invalidation
+// Send parentId to move the category (null makes it top-level)
 func UpdateCategory(c *gin.Context) {
 	id := c.Param("id")
 	var category models.Category
 	if err := db.DB.First(&category, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCategoryNotFound)
 		return
 	}
+	oldName, oldSlug, oldParentID := category.Name, category.Slug, category.ParentID
 	if err := c.ShouldBindJSON(&category); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.BadRequest(c, err)
 		return
 	}
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
-	if err := db.DB.Save(&category).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+
+	reparented := (oldParentID == nil) != (category.ParentID == nil) ||
+		(oldParentID != nil && *oldParentID != *category.ParentID)
+	if reparented {
+		if code := validateCategoryParent(db.DB, category.ID, category.ParentID); code != "" {
+			utils.ErrorResponse(c, http.StatusBadRequest, code)
+			return
+		}
+	}
+
+	// A rename regenerates the slug unless one is given explicitly
+	requestedSlug := ""
+	if category.Slug != oldSlug {
+		requestedSlug = category.Slug
+	}
+	if requestedSlug != "" || category.Name != oldName {
+		slug, err := pickSlug(db.DB, models.SlugEntityCategory, category.ID, requestedSlug, category.Name)
+		if code := slugErrorCode(err); code != "" {
+			utils.ErrorResponse(c, http.StatusBadRequest, code)
+			return
+		}
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCategoryFail)
+			return
+		}
+		category.Slug = slug
+	}
+	category.Children = nil
+
+	// Game lists of the old ancestors change as well when the category moves
+	scope := categoryCacheScope(db.DB, category.ID)
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&category).Error; err != nil {
+			return err
+		}
+		return moveSlug(tx, models.SlugEntityCategory, category.ID, oldSlug, category.Slug)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateCategoryFail)
 		return
 	}
 
 	// Invalidate caches
 	if cache.IsRedisAvailable() {
-		cache.InvalidateCategories()
+		if reparented {
+			scope = append(scope, categoryCacheScope(db.DB, category.ID)...)
+		}
+		cache.InvalidateCategories(scope...)
 		cache.InvalidateGamesList() // Games include category info
+		cache.InvalidateSlug(models.SlugEntityCategory, oldSlug)
 		utils.Log.Info("Categories and games cache invalidated after update")
 	}
 
 	c.JSON(http.StatusOK, category)
 }
 
+// reassignCategoryGames moves every game of one category to another
+// and returns the IDs of the moved games
+func reassignCategoryGames(tx *gorm.DB, fromID, toID uint) ([]uint, error) {
+	var gameIDs []uint
+	if err := tx.Model(&models.Game{}).Where("category_id = ?", fromID).Pluck("id", &gameIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(gameIDs) == 0 {
+		return nil, nil
+	}
+	err := tx.Model(&models.Game{}).Where("id IN ?", gameIDs).Update("category_id", toID).Error
+	return gameIDs, err
+}
+
 // DeleteCategory with cache invalidation
+// A category that still has games needs ?moveTo=<id or slug> naming the
+// category its games move to. Subcategories move up to the deleted category's parent.
 func DeleteCategory(c *gin.Context) {
 	id := c.Param("id")
 	var category models.Category
 	if err := db.DB.First(&category, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCategoryNotFound)
 		return
 	}
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var gameCount int64
+	if err := db.DB.Model(&models.Game{}).Where("category_id = ?", category.ID).Count(&gameCount).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgDeleteCategoryFail)
+		return
+	}
+
+	var target models.Category
+	if moveTo := c.Query("moveTo"); moveTo != "" {
+		targetID, _, err := resolveSlugRef(models.SlugEntityCategory, moveTo)
+		if err != nil || db.DB.First(&target, targetID).Error != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, utils.MsgTargetCategoryNotFound)
+			return
+		}
+		if target.ID == category.ID {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidTargetCategory)
+			return
+		}
+	} else if gameCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      utils.Message(c, utils.MsgCategoryInUse),
+			"code":       utils.MsgCategoryInUse,
+			"game_count": gameCount,
+		})
 		return
 	}
-	if err := db.DB.Delete(&category).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+
+	scope := categoryCacheScope(db.DB, category.ID)
+	if target.ID != 0 {
+		scope = append(scope, categoryCacheScope(db.DB, target.ID)...)
+	}
+
+	var movedGameIDs []uint
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if target.ID != 0 {
+			var err error
+			if movedGameIDs, err = reassignCategoryGames(tx, category.ID, target.ID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Category{}).
+			Where("parent_id = ?", category.ID).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("entity = ? AND target_id = ?", models.SlugEntityCategory, category.ID).Delete(&models.SlugRedirect{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgDeleteCategoryFail)
 		return
 	}
 
 	// Invalidate caches
 	if cache.IsRedisAvailable() {
-		cache.InvalidateCategories()
+		cache.InvalidateCategories(scope...)
 		cache.InvalidateGamesList()
+		cache.InvalidateSlug(models.SlugEntityCategory, category.Slug)
+		for _, gameID := range movedGameIDs {
+			cache.InvalidateGame(gameID)
+		}
 		utils.Log.Info("Categories and games cache invalidated after deletion")
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Category deleted",
+		"moved_games": len(movedGameIDs),
+	})
+}
+
+// MergeCategories - admin operation folding source categories into a target.
+// Games and subcategories move to the target, and source slugs redirect to it.
+// POST /admin/categories/merge
+func MergeCategories(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var input models.CategoryMergeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var target models.Category
+	if err := db.DB.First(&target, input.TargetID).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgTargetCategoryNotFound)
+		return
+	}
+
+	sourceIDs := uniqueIDs(input.SourceIDs)
+	var sources []models.Category
+	if err := db.DB.Where("id IN ?", sourceIDs).Find(&sources).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgMergeCategoriesFail)
+		return
+	}
+	if len(sources) != len(sourceIDs) {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCategoryNotFound)
+		return
+	}
+
+	scope := categoryCacheScope(db.DB, target.ID)
+	for _, source := range sources {
+		if source.ID == target.ID {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidTargetCategory)
+			return
+		}
+		// The source's subcategories move under the target, which must not be one of them
+		if code := validateCategoryParent(db.DB, source.ID, &target.ID); code != "" {
+			utils.ErrorResponse(c, http.StatusBadRequest, code)
+			return
+		}
+		scope = append(scope, categoryCacheScope(db.DB, source.ID)...)
+	}
+
+	var movedGameIDs []uint
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, source := range sources {
+			moved, err := reassignCategoryGames(tx, source.ID, target.ID)
+			if err != nil {
+				return err
+			}
+			movedGameIDs = append(movedGameIDs, moved...)
+
+			if err := tx.Model(&models.Category{}).
+				Where("parent_id = ?", source.ID).
+				Update("parent_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.SlugRedirect{}).
+				Where("entity = ? AND target_id = ?", models.SlugEntityCategory, source.ID).
+				Update("target_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&source).Error; err != nil {
+				return err
+			}
+			if err := moveSlug(tx, models.SlugEntityCategory, target.ID, source.Slug, target.Slug); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgMergeCategoriesFail)
+		return
+	}
+
+	// Invalidate caches
+	if cache.IsRedisAvailable() {
+		cache.InvalidateCategories(scope...)
+		cache.InvalidateGamesList()
+		for _, source := range sources {
+			cache.InvalidateSlug(models.SlugEntityCategory, source.Slug)
+		}
+		for _, gameID := range movedGameIDs {
+			cache.InvalidateGame(gameID)
+		}
+		utils.Log.Info(fmt.Sprintf("Merged %d categories into category %d", len(sources), target.ID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Categories merged",
+		"target":      target,
+		"moved_games": len(movedGameIDs),
+	})
 }
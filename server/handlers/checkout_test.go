@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/mail"
+	"awesomeProject/models"
+	"awesomeProject/payments"
+	"awesomeProject/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB points db.DB at the database in TEST_DATABASE_URL and migrates
+// it. Tests that need a database are skipped without one.
+func openTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	db.DB = conn
+	db.Migrate()
+
+	utils.Log = logrus.New()
+	utils.Log.SetOutput(io.Discard)
+	mail.Register(mail.LogSender{})
+	gin.SetMode(gin.TestMode)
+}
+
+func TestCheckoutWebhookSettle(t *testing.T) {
+	openTestDB(t)
+	suffix := time.Now().UnixNano()
+
+	developer := models.User{Email: fmt.Sprintf("dev-%d@example.com", suffix), Password: "x", Name: "Developer", Role: "developer"}
+	buyer := models.User{Email: fmt.Sprintf("buyer-%d@example.com", suffix), Password: "x", Name: "Buyer", Role: "user"}
+	category := models.Category{Name: "Checkout", Slug: fmt.Sprintf("checkout-%d", suffix)}
+	for _, row := range []interface{}{&developer, &buyer, &category} {
+		if err := db.DB.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	game := models.Game{
+		Name:        "Checkout Test",
+		Slug:        fmt.Sprintf("checkout-test-%d", suffix),
+		Price:       19.99,
+		CategoryID:  category.ID,
+		DeveloperID: developer.ID,
+	}
+	if err := db.DB.Create(&game).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &payments.FakeProvider{Secret: "test-secret", Outcome: payments.StatusPending}
+	payments.Register(provider)
+
+	order, payment, err := checkout(buyer, provider, models.Order{Items: orderItemsFor([]models.Game{game})}, nil)
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	if order.Status != models.OrderStatusPending || payment.Status != payments.StatusPending {
+		t.Fatalf("order %s, payment %s, want both pending", order.Status, payment.Status)
+	}
+	if order.Total != game.Price {
+		t.Errorf("total = %v, want %v", order.Total, game.Price)
+	}
+
+	// The game can't be ordered again while the payment is pending
+	_, _, err = checkout(buyer, provider, models.Order{Items: orderItemsFor([]models.Game{game})}, nil)
+	var conflict purchaseConflict
+	if !errors.As(err, &conflict) || conflict.code != utils.MsgPaymentPending {
+		t.Fatalf("second checkout err = %v, want %s", err, utils.MsgPaymentPending)
+	}
+
+	router := gin.New()
+	router.POST("/payments/webhook/:provider", PaymentWebhook)
+	webhook := func(payload []byte, signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/payments/webhook/"+provider.Name(), bytes.NewReader(payload))
+		req.Header.Set(payments.SignatureHeader, signature)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	payload, _ := json.Marshal(payments.WebhookEvent{Reference: payment.Reference, Status: payments.StatusPaid})
+	if code := webhook(payload, "bad-signature"); code != http.StatusUnauthorized {
+		t.Errorf("unsigned webhook status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	for i := 0; i < 2; i++ {
+		if code := webhook(payload, provider.Sign(payload)); code != http.StatusOK {
+			t.Fatalf("webhook %d status = %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+
+	var settled models.Order
+	if err := db.DB.Preload("Receipt").First(&settled, order.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if settled.Status != models.OrderStatusPaid || settled.PaidAt == nil {
+		t.Errorf("order status = %s, want paid", settled.Status)
+	}
+	if settled.Receipt == nil {
+		t.Error("paid order has no receipt")
+	}
+
+	var ownerships []models.Ownership
+	if err := db.DB.Where("user_id = ? AND game_id = ?", buyer.ID, game.ID).Find(&ownerships).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ownerships) != 1 || ownerships[0].Status != "owned" {
+		t.Fatalf("ownerships = %+v, want one owned", ownerships)
+	}
+
+	var sales int64
+	if err := db.DB.Model(&models.EarningEntry{}).Where("order_id = ?", order.ID).Count(&sales).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sales != 1 {
+		t.Errorf("earning entries = %d, want 1", sales)
+	}
+
+	_, _, err = checkout(buyer, provider, models.Order{Items: orderItemsFor([]models.Game{game})}, nil)
+	if !errors.As(err, &conflict) || conflict.code != utils.MsgGameAlreadyOwned {
+		t.Errorf("checkout after settle err = %v, want %s", err, utils.MsgGameAlreadyOwned)
+	}
+}
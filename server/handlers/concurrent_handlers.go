@@ -4,7 +4,10 @@ import (
 	"awesomeProject/concurrent"
 	"awesomeProject/db"
 	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"net/http"
 	"strconv"
 	"time"
@@ -16,7 +19,7 @@ func GetGameDetailsAdvanced(c *gin.Context) {
 	id := c.Param("id")
 	gameID, err := strconv.Atoi(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidGameID)
 		return
 	}
 
@@ -26,14 +29,50 @@ func GetGameDetailsAdvanced(c *gin.Context) {
 	duration := time.Since(start)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
+			return
+		}
+		utils.LogError("Failed to fetch game details", map[string]interface{}{
+			"gameId": gameID,
+			"error":  err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGameDetailsFailed)
 		return
 	}
 
+	// Дополнения с информацией о владении для текущего пользователя
+	user := c.MustGet("user").(models.User)
+	addOnIDs := make([]uint, len(details.AddOns))
+	for i, addOn := range details.AddOns {
+		addOnIDs[i] = addOn.ID
+	}
+	owned := ownedGameIDs(db.DB, user.ID, addOnIDs)
+
+	addOns := make([]gin.H, len(details.AddOns))
+	for i, addOn := range details.AddOns {
+		addOns[i] = gin.H{
+			"game":  addOn,
+			"owned": owned[addOn.ID],
+		}
+	}
+
+	// Другие игры серии с информацией о владении
+	var series gin.H
+	if details.Series != nil {
+		series = gin.H{
+			"id":      details.Series.ID,
+			"name":    details.Series.Name,
+			"entries": seriesEntryViews(user.ID, details.Series.Entries),
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"game":          details.Game,
 		"reviews":       details.Reviews,
 		"related_games": details.RelatedGames,
+		"add_ons":       addOns,
+		"series":        series,
 		"statistics":    details.Statistics,
 		"fetch_time_ms": duration.Milliseconds(),
 	})
@@ -44,7 +83,7 @@ func GetGameDetailsAdvanced(c *gin.Context) {
 func BulkUpdateGamePrices(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
 
@@ -55,7 +94,7 @@ func BulkUpdateGamePrices(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.BadRequest(c, err)
 		return
 	}
 
@@ -65,10 +104,13 @@ func BulkUpdateGamePrices(c *gin.Context) {
 	if input.CategoryID != nil {
 		query = query.Where("category_id = ?", *input.CategoryID)
 	}
-	query.Find(&games)
+	if err := query.Find(&games).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGamesFailed)
+		return
+	}
 
 	if len(games) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No games found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgNoGamesFound)
 		return
 	}
 
@@ -78,10 +120,19 @@ func BulkUpdateGamePrices(c *gin.Context) {
 	duration := time.Since(start)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.LogError("Bulk game update failed", map[string]interface{}{
+			"action": input.Action,
+			"error":  err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgBulkUpdateFailed)
 		return
 	}
 
+	// Записываем ревизии для игр с измененной ценой
+	if input.Action == "update_prices" {
+		recordBulkPriceRevisions(user, games)
+	}
+
 	// Подсчет успешных/неуспешных операций
 	successful := 0
 	failed := 0
@@ -102,26 +153,56 @@ func BulkUpdateGamePrices(c *gin.Context) {
 	})
 }
 
+// recordBulkPriceRevisions - ревизии и оповещения о снижении цены для игр, измененных массовым обновлением
+func recordBulkPriceRevisions(editor models.User, games []models.Game) {
+	ids := make([]uint, len(games))
+	for i, game := range games {
+		ids[i] = game.ID
+	}
+
+	var updated []models.Game
+	if err := db.DB.Where("id IN ?", ids).Find(&updated).Error; err != nil {
+		utils.Log.Error("Failed to load games for revisions: " + err.Error())
+		return
+	}
+	byID := make(map[uint]models.Game, len(updated))
+	for _, game := range updated {
+		byID[game.ID] = game
+	}
+
+	for _, game := range games {
+		current, ok := byID[game.ID]
+		if !ok {
+			continue
+		}
+		before := models.SnapshotGame(game)
+		if err := recordGameRevision(db.DB, editor, models.RevisionUpdate, before, current, nil); err != nil {
+			utils.Log.Error("Failed to record revision: " + err.Error())
+		}
+		notifyPriceDrop(before, current)
+	}
+}
+
 // SendGameReleaseNotifications - отправка уведомлений о новой игре
 // POST /games/:id/notify
 func SendGameReleaseNotifications(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" && user.Role != "developer" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
 		return
 	}
 
 	id := c.Param("id")
 	gameID, err := strconv.Atoi(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidGameID)
 		return
 	}
 
 	// Получаем игру
 	var game models.Game
 	if err := db.DB.First(&game, gameID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
 		return
 	}
 
@@ -145,7 +226,11 @@ func SendGameReleaseNotifications(c *gin.Context) {
 	duration := time.Since(start)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.LogError("Failed to send release notifications", map[string]interface{}{
+			"gameId": gameID,
+			"error":  err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSendNotificationsFailed)
 		return
 	}
 
@@ -175,7 +260,7 @@ func SendGameReleaseNotifications(c *gin.Context) {
 func GetDashboardStatistics(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
 
@@ -185,7 +270,10 @@ func GetDashboardStatistics(c *gin.Context) {
 	duration := time.Since(start)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.LogError("Failed to calculate dashboard statistics", map[string]interface{}{
+			"error": err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchStatisticsFailed)
 		return
 	}
 
@@ -200,14 +288,18 @@ func GetDashboardStatistics(c *gin.Context) {
 func SearchGamesAdvanced(c *gin.Context) {
 	query := c.Query("q")
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgSearchQueryRequired)
 		return
 	}
 
 	// Параллельный поиск
 	result, err := concurrent.ParallelSearch(query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.LogError("Parallel search failed", map[string]interface{}{
+			"query": query,
+			"error": err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSearchFailed)
 		return
 	}
 
@@ -224,13 +316,16 @@ func SearchGamesAdvanced(c *gin.Context) {
 func ValidateAllGames(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
 
 	// Получаем все игры
 	var games []models.Game
-	db.DB.Find(&games)
+	if err := db.DB.Find(&games).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGamesFailed)
+		return
+	}
 
 	if len(games) == 0 {
 		c.JSON(http.StatusOK, gin.H{"message": "No games to validate"})
@@ -243,7 +338,10 @@ func ValidateAllGames(c *gin.Context) {
 	duration := time.Since(start)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.LogError("Game validation failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgValidateGamesFailed)
 		return
 	}
 
@@ -282,14 +380,14 @@ func ValidateAllGames(c *gin.Context) {
 func ProcessGameImages(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" && user.Role != "developer" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
 		return
 	}
 
 	id := c.Param("id")
 	gameID, err := strconv.Atoi(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidGameID)
 		return
 	}
 
@@ -318,7 +416,11 @@ func ProcessGameImages(c *gin.Context) {
 	duration := time.Since(start)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		utils.LogError("Image processing failed", map[string]interface{}{
+			"gameId": gameID,
+			"error":  err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgProcessImagesFailed)
 		return
 	}
 
@@ -337,7 +439,7 @@ func GetUserLibraryWithDetails(c *gin.Context) {
 
 	// Получаем игры пользователя
 	var ownerships []models.Ownership
-	db.DB.Where("user_id = ?", user.ID).Preload("Game").Find(&ownerships)
+	db.DB.Where("user_id = ? AND status = ?", user.ID, "owned").Preload("Game").Find(&ownerships)
 
 	if len(ownerships) == 0 {
 		c.JSON(http.StatusOK, gin.H{
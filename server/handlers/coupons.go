@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// couponError - a promo code that can't be applied to the order
+type couponError struct {
+	code utils.MessageCode
+}
+
+func (e couponError) Error() string {
+	return "coupon rejected: " + string(e.code)
+}
+
+// normalizeCouponCode makes codes case-insensitive
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// couponEligibleGameIDs returns which of the games the coupon discounts.
+// Category restrictions include subcategories.
+func couponEligibleGameIDs(tx *gorm.DB, coupon models.Coupon, gameIDs []uint) (map[uint]bool, error) {
+	eligible := make(map[uint]bool)
+	if len(coupon.Games) == 0 && len(coupon.Categories) == 0 {
+		for _, id := range gameIDs {
+			eligible[id] = true
+		}
+		return eligible, nil
+	}
+
+	for _, game := range coupon.Games {
+		eligible[game.ID] = true
+	}
+
+	var categoryIDs []uint
+	for _, category := range coupon.Categories {
+		subtree, err := categorySubtreeIDs(tx, category.ID)
+		if err != nil {
+			return nil, err
+		}
+		categoryIDs = append(categoryIDs, subtree...)
+	}
+	if len(categoryIDs) > 0 {
+		var ids []uint
+		if err := tx.Model(&models.Game{}).Where("id IN ? AND category_id IN ?", gameIDs, categoryIDs).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			eligible[id] = true
+		}
+	}
+	return eligible, nil
+}
+
+// applyCoupon checks the order's promo code and takes the discount off the
+// eligible items. The coupon row stays locked until the order is created,
+// so usage caps hold under concurrent checkouts.
+func applyCoupon(tx *gorm.DB, userID uint, order *models.Order) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", normalizeCouponCode(order.CouponCode)).First(&coupon).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return coupon, couponError{utils.MsgCouponInvalid}
+	}
+	if err != nil {
+		return coupon, err
+	}
+	if err := tx.Model(&coupon).Association("Games").Find(&coupon.Games); err != nil {
+		return coupon, err
+	}
+	if err := tx.Model(&coupon).Association("Categories").Find(&coupon.Categories); err != nil {
+		return coupon, err
+	}
+
+	now := time.Now()
+	if !coupon.Active || (coupon.StartsAt != nil && now.Before(*coupon.StartsAt)) {
+		return coupon, couponError{utils.MsgCouponInvalid}
+	}
+	if coupon.ExpiresAt != nil && now.After(*coupon.ExpiresAt) {
+		return coupon, couponError{utils.MsgCouponExpired}
+	}
+	if coupon.MaxUses != nil && coupon.Uses >= *coupon.MaxUses {
+		return coupon, couponError{utils.MsgCouponExhausted}
+	}
+	if coupon.MaxUsesPerUser != nil {
+		var used int64
+		if err := tx.Model(&models.CouponRedemption{}).
+			Where("coupon_id = ? AND user_id = ?", coupon.ID, userID).Count(&used).Error; err != nil {
+			return coupon, err
+		}
+		if used >= int64(*coupon.MaxUsesPerUser) {
+			return coupon, couponError{utils.MsgCouponUserLimit}
+		}
+	}
+
+	gameIDs := make([]uint, len(order.Items))
+	for i, item := range order.Items {
+		gameIDs[i] = item.GameID
+	}
+	eligible, err := couponEligibleGameIDs(tx, coupon, gameIDs)
+	if err != nil {
+		return coupon, err
+	}
+
+	discount, err := splitCouponDiscount(coupon, order.Items, eligible)
+	if err != nil {
+		return coupon, err
+	}
+
+	order.CouponID = &coupon.ID
+	order.CouponCode = coupon.Code
+	order.Discount = discount
+	return coupon, nil
+}
+
+// splitCouponDiscount takes the coupon's discount off the eligible paid
+// items and returns the total taken off. A fixed amount is spread over the
+// items by price, the last item takes the rounding remainder.
+func splitCouponDiscount(coupon models.Coupon, items []models.OrderItem, eligible map[uint]bool) (float64, error) {
+	var subtotal float64
+	var discounted []int
+	for i, item := range items {
+		if eligible[item.GameID] && item.Price > 0 {
+			subtotal += item.Price
+			discounted = append(discounted, i)
+		}
+	}
+	if len(discounted) == 0 {
+		return 0, couponError{utils.MsgCouponNotApplicable}
+	}
+	if subtotal < coupon.MinSpend {
+		return 0, couponError{utils.MsgCouponMinSpend}
+	}
+
+	discount := math.Min(coupon.Value, subtotal)
+	if coupon.Type == models.CouponTypePercent {
+		discount = subtotal * coupon.Value / 100
+	}
+	discount = math.Round(discount*100) / 100
+
+	remaining := discount
+	for n, i := range discounted {
+		item := &items[i]
+		share := math.Round(discount*item.Price/subtotal*100) / 100
+		if n == len(discounted)-1 {
+			share = remaining
+		}
+		share = math.Min(share, item.Price)
+		item.CouponDiscount = share
+		item.Price = math.Round((item.Price-share)*100) / 100
+		remaining -= share
+	}
+
+	return discount, nil
+}
+
+// redeemCoupon counts the use of the coupon by the created order
+func redeemCoupon(tx *gorm.DB, coupon models.Coupon, order models.Order) error {
+	redemption := models.CouponRedemption{
+		CouponID: coupon.ID,
+		UserID:   order.UserID,
+		OrderID:  order.ID,
+		Discount: order.Discount,
+	}
+	if err := tx.Create(&redemption).Error; err != nil {
+		return err
+	}
+	return tx.Model(&coupon).Update("uses", gorm.Expr("uses + 1")).Error
+}
+
+// releaseCoupon gives the use back when the order's payment fails
+func releaseCoupon(tx *gorm.DB, order models.Order) error {
+	if order.CouponID == nil {
+		return nil
+	}
+	result := tx.Where("order_id = ?", order.ID).Delete(&models.CouponRedemption{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	return tx.Model(&models.Coupon{}).Where("id = ?", *order.CouponID).
+		Update("uses", gorm.Expr("uses - 1")).Error
+}
+
+// bindCouponInput validates the coupon body and loads its games and categories
+func bindCouponInput(c *gin.Context) (models.CouponInput, []models.Game, []models.Category, bool) {
+	var input models.CouponInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return input, nil, nil, false
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return input, nil, nil, false
+	}
+	if input.Type == models.CouponTypePercent && input.Value > 100 {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidCouponValue)
+		return input, nil, nil, false
+	}
+	if input.StartsAt != nil && input.ExpiresAt != nil && !input.ExpiresAt.After(*input.StartsAt) {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidCouponDates)
+		return input, nil, nil, false
+	}
+
+	var games []models.Game
+	if ids := uniqueIDs(input.GameIDs); len(ids) > 0 {
+		if err := db.DB.Where("id IN ?", ids).Find(&games).Error; err != nil || len(games) != len(ids) {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgGameNotFound)
+			return input, nil, nil, false
+		}
+	}
+	var categories []models.Category
+	if ids := uniqueIDs(input.CategoryIDs); len(ids) > 0 {
+		if err := db.DB.Where("id IN ?", ids).Find(&categories).Error; err != nil || len(categories) != len(ids) {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCategoryNotFound)
+			return input, nil, nil, false
+		}
+	}
+	return input, games, categories, true
+}
+
+// couponCodeTaken reports whether another coupon uses the code
+func couponCodeTaken(code string, exceptID uint) bool {
+	var count int64
+	db.DB.Model(&models.Coupon{}).Where("code = ? AND id <> ?", code, exceptID).Count(&count)
+	return count > 0
+}
+
+// GetCoupons - all promo codes, newest first
+// GET /admin/coupons
+func GetCoupons(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var coupons []models.Coupon
+	if err := db.DB.Preload("Games").Preload("Categories").Order("id DESC").Find(&coupons).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchCouponsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, coupons)
+}
+
+// GetCoupon - one promo code
+// GET /admin/coupons/:id
+func GetCoupon(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var coupon models.Coupon
+	if err := db.DB.Preload("Games").Preload("Categories").First(&coupon, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCouponNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, coupon)
+}
+
+// CreateCoupon - add a promo code
+// POST /admin/coupons
+func CreateCoupon(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	input, games, categories, ok := bindCouponInput(c)
+	if !ok {
+		return
+	}
+	code := normalizeCouponCode(input.Code)
+	if couponCodeTaken(code, 0) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgCouponCodeTaken)
+		return
+	}
+
+	coupon := models.Coupon{
+		Code:           code,
+		Type:           input.Type,
+		Value:          input.Value,
+		Games:          games,
+		Categories:     categories,
+		MinSpend:       input.MinSpend,
+		MaxUses:        input.MaxUses,
+		MaxUsesPerUser: input.MaxUsesPerUser,
+		StartsAt:       input.StartsAt,
+		ExpiresAt:      input.ExpiresAt,
+		Active:         input.Active == nil || *input.Active,
+		CreatedByID:    user.ID,
+	}
+	if err := db.DB.Create(&coupon).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveCouponFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Coupon %s created by admin %d", coupon.Code, user.ID))
+	c.JSON(http.StatusCreated, coupon)
+}
+
+// UpdateCoupon - change a promo code. Orders keep the discount they got.
+// PUT /admin/coupons/:id
+func UpdateCoupon(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var coupon models.Coupon
+	if err := db.DB.First(&coupon, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCouponNotFound)
+		return
+	}
+
+	input, games, categories, ok := bindCouponInput(c)
+	if !ok {
+		return
+	}
+	code := normalizeCouponCode(input.Code)
+	if couponCodeTaken(code, coupon.ID) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgCouponCodeTaken)
+		return
+	}
+
+	coupon.Code = code
+	coupon.Type = input.Type
+	coupon.Value = input.Value
+	coupon.MinSpend = input.MinSpend
+	coupon.MaxUses = input.MaxUses
+	coupon.MaxUsesPerUser = input.MaxUsesPerUser
+	coupon.StartsAt = input.StartsAt
+	coupon.ExpiresAt = input.ExpiresAt
+	if input.Active != nil {
+		coupon.Active = *input.Active
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Games", "Categories").Save(&coupon).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&coupon).Association("Games").Replace(games); err != nil {
+			return err
+		}
+		return tx.Model(&coupon).Association("Categories").Replace(categories)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveCouponFailed)
+		return
+	}
+
+	coupon.Games = games
+	coupon.Categories = categories
+	c.JSON(http.StatusOK, coupon)
+}
+
+// DeleteCoupon - remove a promo code that was never redeemed
+// DELETE /admin/coupons/:id
+func DeleteCoupon(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var coupon models.Coupon
+	if err := db.DB.First(&coupon, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCouponNotFound)
+		return
+	}
+
+	var redemptions int64
+	db.DB.Model(&models.CouponRedemption{}).Where("coupon_id = ?", coupon.ID).Count(&redemptions)
+	if redemptions > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgCouponInUse)
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&coupon).Association("Games").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(&coupon).Association("Categories").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&coupon).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveCouponFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon deleted"})
+}
+
+// couponRedemptionRow - one line of the redemption report
+type couponRedemptionRow struct {
+	models.CouponRedemption
+	OrderStatus string  `json:"orderStatus"`
+	OrderTotal  float64 `json:"orderTotal"`
+}
+
+// GetCouponRedemptions - who used a promo code, with totals
+// GET /admin/coupons/:id/redemptions
+func GetCouponRedemptions(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var coupon models.Coupon
+	if err := db.DB.First(&coupon, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCouponNotFound)
+		return
+	}
+
+	var rows []couponRedemptionRow
+	err := db.DB.Model(&models.CouponRedemption{}).
+		Select("coupon_redemptions.*, orders.status AS order_status, orders.total AS order_total").
+		Joins("JOIN orders ON orders.id = coupon_redemptions.order_id").
+		Where("coupon_redemptions.coupon_id = ?", coupon.ID).
+		Order("coupon_redemptions.id DESC").
+		Scan(&rows).Error
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchCouponsFailed)
+		return
+	}
+
+	// Totals cover paid orders only
+	var totalDiscount, revenue float64
+	paidOrders := 0
+	users := make(map[uint]bool)
+	for _, row := range rows {
+		if row.OrderStatus != models.OrderStatusPaid {
+			continue
+		}
+		paidOrders++
+		totalDiscount += row.Discount
+		revenue += row.OrderTotal
+		users[row.UserID] = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coupon":        coupon,
+		"redemptions":   rows,
+		"paidOrders":    paidOrders,
+		"uniqueUsers":   len(users),
+		"totalDiscount": math.Round(totalDiscount*100) / 100,
+		"revenue":       math.Round(revenue*100) / 100,
+	})
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSplitCouponDiscount(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   models.Coupon
+		prices   []float64
+		eligible map[uint]bool
+		discount float64
+		shares   []float64
+		code     utils.MessageCode
+	}{
+		{
+			name:     "percent rounds to cents",
+			coupon:   models.Coupon{Type: models.CouponTypePercent, Value: 10},
+			prices:   []float64{19.99, 9.99},
+			eligible: map[uint]bool{1: true, 2: true},
+			discount: 3,
+			shares:   []float64{2, 1},
+		},
+		{
+			name:     "fixed spread by price over eligible items",
+			coupon:   models.Coupon{Type: models.CouponTypeFixed, Value: 10},
+			prices:   []float64{5, 15, 30},
+			eligible: map[uint]bool{1: true, 2: true},
+			discount: 10,
+			shares:   []float64{2.5, 7.5, 0},
+		},
+		{
+			name:     "last item takes the remainder",
+			coupon:   models.Coupon{Type: models.CouponTypeFixed, Value: 10},
+			prices:   []float64{20, 20, 20},
+			eligible: map[uint]bool{1: true, 2: true, 3: true},
+			discount: 10,
+			shares:   []float64{3.33, 3.33, 3.34},
+		},
+		{
+			name:     "fixed capped at the subtotal",
+			coupon:   models.Coupon{Type: models.CouponTypeFixed, Value: 10},
+			prices:   []float64{4, 3},
+			eligible: map[uint]bool{1: true, 2: true},
+			discount: 7,
+			shares:   []float64{4, 3},
+		},
+		{
+			name:     "free items are skipped",
+			coupon:   models.Coupon{Type: models.CouponTypePercent, Value: 50},
+			prices:   []float64{0, 10},
+			eligible: map[uint]bool{1: true, 2: true},
+			discount: 5,
+			shares:   []float64{0, 5},
+		},
+		{
+			name:     "no eligible items",
+			coupon:   models.Coupon{Type: models.CouponTypePercent, Value: 50},
+			prices:   []float64{10, 0},
+			eligible: map[uint]bool{2: true},
+			code:     utils.MsgCouponNotApplicable,
+		},
+		{
+			name:     "below minimum spend",
+			coupon:   models.Coupon{Type: models.CouponTypeFixed, Value: 5, MinSpend: 20},
+			prices:   []float64{10, 15},
+			eligible: map[uint]bool{1: true},
+			code:     utils.MsgCouponMinSpend,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]models.OrderItem, len(tt.prices))
+			for i, price := range tt.prices {
+				items[i] = models.OrderItem{GameID: uint(i + 1), ListPrice: price, Price: price}
+			}
+
+			discount, err := splitCouponDiscount(tt.coupon, items, tt.eligible)
+			if tt.code != "" {
+				var rejected couponError
+				if !errors.As(err, &rejected) || rejected.code != tt.code {
+					t.Fatalf("err = %v, want %s", err, tt.code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if discount != tt.discount {
+				t.Errorf("discount = %v, want %v", discount, tt.discount)
+			}
+
+			shares := make([]float64, len(items))
+			var total float64
+			for i, item := range items {
+				shares[i] = item.CouponDiscount
+				total += item.CouponDiscount
+				if item.Price != math.Round((item.ListPrice-item.CouponDiscount)*100)/100 {
+					t.Errorf("item %d price = %v after a %v discount off %v", i, item.Price, item.CouponDiscount, item.ListPrice)
+				}
+			}
+			if !reflect.DeepEqual(shares, tt.shares) {
+				t.Errorf("shares = %v, want %v", shares, tt.shares)
+			}
+			if math.Abs(total-discount) > 1e-9 {
+				t.Errorf("shares add up to %v, want %v", total, discount)
+			}
+		})
+	}
+}
@@ -0,0 +1,719 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/revenue"
+	"awesomeProject/utils"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	errInsufficientEarnings = errors.New("amount exceeds available earnings")
+	errPayoutNotPending     = errors.New("payout request already reviewed")
+)
+
+// gamePayee returns who earns from sales of the game
+func gamePayee(game models.Game) (string, uint) {
+	if game.OrganizationID != nil {
+		return models.PayeeOrganization, *game.OrganizationID
+	}
+	return models.PayeeDeveloper, game.DeveloperID
+}
+
+// payeeFeePercent returns the platform fee agreed with the payee, or the
+// default one
+func payeeFeePercent(tx *gorm.DB, payeeType string, payeeID uint) (float64, error) {
+	var shares []models.RevenueShare
+	if err := tx.Where("payee_type = ? AND payee_id = ?", payeeType, payeeID).Limit(1).Find(&shares).Error; err != nil {
+		return 0, err
+	}
+	if len(shares) == 0 {
+		return revenue.DefaultFeePercent(), nil
+	}
+	return shares[0].FeePercent, nil
+}
+
+// lockPayee serializes payouts of one payee until the transaction ends
+func lockPayee(tx *gorm.DB, payeeType string, payeeID uint) error {
+	kind := 1
+	if payeeType == models.PayeeOrganization {
+		kind = 2
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", kind, payeeID).Error
+}
+
+// earningsBalance sums the payee's ledger
+func earningsBalance(tx *gorm.DB, payeeType string, payeeID uint) (float64, error) {
+	var balance float64
+	err := tx.Model(&models.EarningEntry{}).
+		Where("payee_type = ? AND payee_id = ?", payeeType, payeeID).
+		Select("COALESCE(SUM(net), 0)").
+		Scan(&balance).Error
+	return math.Round(balance*100) / 100, err
+}
+
+// pendingPayoutTotal sums payout requests still waiting for an admin
+func pendingPayoutTotal(tx *gorm.DB, payeeType string, payeeID uint) (float64, error) {
+	var total float64
+	err := tx.Model(&models.PayoutRequest{}).
+		Where("payee_type = ? AND payee_id = ? AND status = ?", payeeType, payeeID, models.PayoutStatusPending).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	return math.Round(total*100) / 100, err
+}
+
+// recordSales credits the payees of a paid order's games with their share.
+// Tax is not part of the split.
+func recordSales(tx *gorm.DB, order models.Order) error {
+	for _, item := range order.Items {
+		gross := item.Price
+		if order.TaxInclusive {
+			gross -= item.Tax
+		}
+		gross = math.Round(gross*100) / 100
+		if gross <= 0 {
+			continue
+		}
+
+		var game models.Game
+		if err := tx.Select("id", "developer_id", "organization_id").First(&game, item.GameID).Error; err != nil {
+			return err
+		}
+		payeeType, payeeID := gamePayee(game)
+		percent, err := payeeFeePercent(tx, payeeType, payeeID)
+		if err != nil {
+			return err
+		}
+
+		fee, net := revenue.Split(gross, percent)
+		entry := models.EarningEntry{
+			PayeeType:   payeeType,
+			PayeeID:     payeeID,
+			Kind:        models.EarningKindSale,
+			GameID:      &item.GameID,
+			OrderID:     &order.ID,
+			OrderItemID: &item.ID,
+			Gross:       gross,
+			FeePercent:  percent,
+			Fee:         fee,
+			Net:         net,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clawbackSale reverses the earnings of a refunded order item. The payee
+// gives back what they earned, the platform its fee.
+func clawbackSale(tx *gorm.DB, itemID uint) error {
+	var sales []models.EarningEntry
+	if err := tx.Where("order_item_id = ? AND kind = ?", itemID, models.EarningKindSale).Find(&sales).Error; err != nil {
+		return err
+	}
+	for _, sale := range sales {
+		clawback := models.EarningEntry{
+			PayeeType:   sale.PayeeType,
+			PayeeID:     sale.PayeeID,
+			Kind:        models.EarningKindClawback,
+			GameID:      sale.GameID,
+			OrderID:     sale.OrderID,
+			OrderItemID: sale.OrderItemID,
+			Gross:       -sale.Gross,
+			FeePercent:  sale.FeePercent,
+			Fee:         -sale.Fee,
+			Net:         -sale.Net,
+		}
+		if err := tx.Create(&clawback).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePayee works out whose earnings a request is about: the
+// organization of ?organizationId= for its members with one of the roles,
+// otherwise the developer themselves. Admins may look at any developer
+// with ?developerId=.
+func resolvePayee(c *gin.Context, user models.User, orgID *uint, roles ...string) (string, uint, bool) {
+	if orgID != nil {
+		if !hasOrgRole(user, *orgID, roles...) {
+			utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
+			return "", 0, false
+		}
+		return models.PayeeOrganization, *orgID, true
+	}
+	if user.Role == "admin" && c.Query("developerId") != "" {
+		id, err := strconv.Atoi(c.Query("developerId"))
+		if err != nil || id <= 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidUserID)
+			return "", 0, false
+		}
+		return models.PayeeDeveloper, uint(id), true
+	}
+	if user.Role != "developer" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
+		return "", 0, false
+	}
+	return models.PayeeDeveloper, user.ID, true
+}
+
+// queryOrganizationID reads ?organizationId=, nil when absent or invalid
+func queryOrganizationID(c *gin.Context) *uint {
+	id, err := strconv.Atoi(c.Query("organizationId"))
+	if err != nil || id <= 0 {
+		return nil
+	}
+	orgID := uint(id)
+	return &orgID
+}
+
+// GetEarnings - balance and latest ledger entries of a developer or organization
+// GET /earnings?organizationId=&limit=50
+func GetEarnings(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	payeeType, payeeID, ok := resolvePayee(c, user, queryOrganizationID(c), models.OrgRoleOwner, models.OrgRoleAnalyst)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	balance, err := earningsBalance(db.DB, payeeType, payeeID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchEarningsFailed)
+		return
+	}
+	pending, err := pendingPayoutTotal(db.DB, payeeType, payeeID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchEarningsFailed)
+		return
+	}
+	percent, err := payeeFeePercent(db.DB, payeeType, payeeID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchEarningsFailed)
+		return
+	}
+
+	var entries []models.EarningEntry
+	if err := db.DB.Where("payee_type = ? AND payee_id = ?", payeeType, payeeID).
+		Order("id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchEarningsFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payeeType":      payeeType,
+		"payeeId":        payeeID,
+		"feePercent":     percent,
+		"balance":        balance,
+		"pendingPayouts": pending,
+		"available":      math.Round((balance-pending)*100) / 100,
+		"entries":        entries,
+	})
+}
+
+// statementGame - one game's line of a monthly statement
+type statementGame struct {
+	GameID  uint    `json:"gameId"`
+	Name    string  `json:"name"`
+	Units   int     `json:"units"`
+	Refunds int     `json:"refunds"`
+	Gross   float64 `json:"gross"`
+	Fee     float64 `json:"fee"`
+	Net     float64 `json:"net"`
+}
+
+// earningsStatement - what a payee earned and was paid in one month
+type earningsStatement struct {
+	PayeeType      string                `json:"payeeType"`
+	PayeeID        uint                  `json:"payeeId"`
+	Month          string                `json:"month"`
+	OpeningBalance float64               `json:"openingBalance"`
+	Sales          float64               `json:"sales"`
+	Clawbacks      float64               `json:"clawbacks"`
+	Fees           float64               `json:"fees"`
+	Payouts        float64               `json:"payouts"`
+	ClosingBalance float64               `json:"closingBalance"`
+	Games          []statementGame       `json:"games"`
+	Entries        []models.EarningEntry `json:"entries"`
+	gameNames      map[uint]string
+}
+
+// buildStatement sums the payee's ledger for the month starting at from
+func buildStatement(payeeType string, payeeID uint, from time.Time) (earningsStatement, error) {
+	to := from.AddDate(0, 1, 0)
+	statement := earningsStatement{
+		PayeeType: payeeType,
+		PayeeID:   payeeID,
+		Month:     from.Format("2006-01"),
+		Games:     []statementGame{},
+	}
+
+	err := db.DB.Model(&models.EarningEntry{}).
+		Where("payee_type = ? AND payee_id = ? AND created_at < ?", payeeType, payeeID, from).
+		Select("COALESCE(SUM(net), 0)").
+		Scan(&statement.OpeningBalance).Error
+	if err != nil {
+		return statement, err
+	}
+	if err := db.DB.Where("payee_type = ? AND payee_id = ? AND created_at >= ? AND created_at < ?", payeeType, payeeID, from, to).
+		Order("id").Find(&statement.Entries).Error; err != nil {
+		return statement, err
+	}
+
+	var gameIDs []uint
+	games := map[uint]*statementGame{}
+	balance := statement.OpeningBalance
+	for _, entry := range statement.Entries {
+		balance += entry.Net
+		switch entry.Kind {
+		case models.EarningKindSale:
+			statement.Sales += entry.Gross
+		case models.EarningKindClawback:
+			statement.Clawbacks += entry.Gross
+		case models.EarningKindPayout:
+			statement.Payouts += entry.Net
+		}
+		statement.Fees += entry.Fee
+
+		if entry.GameID == nil {
+			continue
+		}
+		game, found := games[*entry.GameID]
+		if !found {
+			game = &statementGame{GameID: *entry.GameID}
+			games[*entry.GameID] = game
+			gameIDs = append(gameIDs, *entry.GameID)
+		}
+		if entry.Kind == models.EarningKindSale {
+			game.Units++
+		} else {
+			game.Refunds++
+		}
+		game.Gross += entry.Gross
+		game.Fee += entry.Fee
+		game.Net += entry.Net
+	}
+
+	statement.gameNames = map[uint]string{}
+	if len(gameIDs) > 0 {
+		var rows []models.Game
+		if err := db.DB.Select("id", "name").Where("id IN ?", gameIDs).Find(&rows).Error; err != nil {
+			return statement, err
+		}
+		for _, row := range rows {
+			statement.gameNames[row.ID] = row.Name
+		}
+	}
+	for _, id := range gameIDs {
+		game := games[id]
+		game.Name = statement.gameNames[id]
+		game.Gross = math.Round(game.Gross*100) / 100
+		game.Fee = math.Round(game.Fee*100) / 100
+		game.Net = math.Round(game.Net*100) / 100
+		statement.Games = append(statement.Games, *game)
+	}
+	sort.Slice(statement.Games, func(i, j int) bool {
+		return statement.Games[i].Net > statement.Games[j].Net
+	})
+
+	statement.OpeningBalance = math.Round(statement.OpeningBalance*100) / 100
+	statement.Sales = math.Round(statement.Sales*100) / 100
+	statement.Clawbacks = math.Round(statement.Clawbacks*100) / 100
+	statement.Fees = math.Round(statement.Fees*100) / 100
+	statement.Payouts = math.Round(statement.Payouts*100) / 100
+	statement.ClosingBalance = math.Round(balance*100) / 100
+	return statement, nil
+}
+
+// optionalID formats a nullable ID for CSV
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// writeStatementCSV writes the statement's ledger lines between its opening
+// and closing balance
+func writeStatementCSV(w io.Writer, statement earningsStatement) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "kind", "game_id", "game", "order_id", "payout_id", "gross", "fee_percent", "fee", "net"})
+	writer.Write([]string{statement.Month + "-01", "opening_balance", "", "", "", "", "", "", "", money(statement.OpeningBalance)})
+	for _, entry := range statement.Entries {
+		game := ""
+		if entry.GameID != nil {
+			game = statement.gameNames[*entry.GameID]
+		}
+		writer.Write([]string{
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.Kind,
+			optionalID(entry.GameID),
+			game,
+			optionalID(entry.OrderID),
+			optionalID(entry.PayoutID),
+			money(entry.Gross),
+			money(entry.FeePercent),
+			money(entry.Fee),
+			money(entry.Net),
+		})
+	}
+	writer.Write([]string{"", "closing_balance", "", "", "", "", money(statement.Sales + statement.Clawbacks), "", money(statement.Fees), money(statement.ClosingBalance)})
+	writer.Flush()
+	return writer.Error()
+}
+
+// money formats an amount with two decimals
+func money(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// GetEarningsStatement - monthly statement, as JSON or CSV
+// GET /earnings/statements?month=2026-09&organizationId=&format=csv
+func GetEarningsStatement(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	payeeType, payeeID, ok := resolvePayee(c, user, queryOrganizationID(c), models.OrgRoleOwner, models.OrgRoleAnalyst)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month := c.Query("month"); month != "" {
+		parsed, err := time.Parse("2006-01", month)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidStatementMonth)
+			return
+		}
+		from = parsed
+	}
+
+	statement, err := buildStatement(payeeType, payeeID, from)
+	if err != nil {
+		utils.LogError("Failed to build earnings statement", map[string]interface{}{
+			"payee_type": payeeType,
+			"payee_id":   payeeID,
+			"error":      err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchEarningsFailed)
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, statement)
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%s-%d-%s.csv", payeeType, payeeID, statement.Month)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := writeStatementCSV(c.Writer, statement); err != nil {
+		utils.LogError("Failed to write earnings statement", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// GetPayouts - payout requests of a developer or organization, newest first
+// GET /earnings/payouts?organizationId=
+func GetPayouts(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	payeeType, payeeID, ok := resolvePayee(c, user, queryOrganizationID(c), models.OrgRoleOwner, models.OrgRoleAnalyst)
+	if !ok {
+		return
+	}
+
+	var payouts []models.PayoutRequest
+	if err := db.DB.Where("payee_type = ? AND payee_id = ?", payeeType, payeeID).Order("id DESC").Find(&payouts).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchEarningsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, payouts)
+}
+
+// RequestPayout - ask for part of the available balance to be paid out.
+// Only organization owners can request an organization's payouts.
+// POST /earnings/payouts
+func RequestPayout(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input models.PayoutRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	payeeType, payeeID, ok := resolvePayee(c, user, input.OrganizationID, models.OrgRoleOwner)
+	if !ok {
+		return
+	}
+
+	payout := models.PayoutRequest{
+		PayeeType:     payeeType,
+		PayeeID:       payeeID,
+		Amount:        math.Round(input.Amount*100) / 100,
+		Status:        models.PayoutStatusPending,
+		RequestedByID: user.ID,
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPayee(tx, payeeType, payeeID); err != nil {
+			return err
+		}
+		balance, err := earningsBalance(tx, payeeType, payeeID)
+		if err != nil {
+			return err
+		}
+		pending, err := pendingPayoutTotal(tx, payeeType, payeeID)
+		if err != nil {
+			return err
+		}
+		if payout.Amount > balance-pending {
+			return errInsufficientEarnings
+		}
+		return tx.Create(&payout).Error
+	})
+	if errors.Is(err, errInsufficientEarnings) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgInsufficientEarnings)
+		return
+	}
+	if err != nil {
+		utils.LogError("Failed to request payout", map[string]interface{}{
+			"payee_type": payeeType,
+			"payee_id":   payeeID,
+			"error":      err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgPayoutRequestFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Payout %d of %.2f requested for %s %d by user %d", payout.ID, payout.Amount, payeeType, payeeID, user.ID))
+	c.JSON(http.StatusCreated, payout)
+}
+
+// GetPayoutQueue - payout requests for review, oldest first
+// GET /admin/payouts?status=pending
+func GetPayoutQueue(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	status := c.DefaultQuery("status", models.PayoutStatusPending)
+
+	var payouts []models.PayoutRequest
+	if err := db.DB.Where("status = ?", status).Order("id ASC").Find(&payouts).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchEarningsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, payouts)
+}
+
+// bindPayoutReview reads the optional admin note
+func bindPayoutReview(c *gin.Context) (models.PayoutReviewInput, bool) {
+	var input models.PayoutReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.BadRequest(c, err)
+		return input, false
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return input, false
+	}
+	return input, true
+}
+
+// reviewPayout settles a pending payout request. Approving debits the
+// payee's ledger, so clawbacks since the request can make it fail.
+func reviewPayout(c *gin.Context, admin models.User, approve bool) {
+	input, ok := bindPayoutReview(c)
+	if !ok {
+		return
+	}
+
+	var payout models.PayoutRequest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payout, c.Param("id")).Error; err != nil {
+			return err
+		}
+		if payout.Status != models.PayoutStatusPending {
+			return errPayoutNotPending
+		}
+
+		now := time.Now()
+		payout.Status = models.PayoutStatusRejected
+		payout.ReviewedByID = &admin.ID
+		payout.ReviewNote = input.Note
+		payout.ReviewedAt = &now
+		if !approve {
+			return tx.Save(&payout).Error
+		}
+
+		if err := lockPayee(tx, payout.PayeeType, payout.PayeeID); err != nil {
+			return err
+		}
+		balance, err := earningsBalance(tx, payout.PayeeType, payout.PayeeID)
+		if err != nil {
+			return err
+		}
+		if payout.Amount > balance {
+			return errInsufficientEarnings
+		}
+
+		payout.Status = models.PayoutStatusApproved
+		if err := tx.Save(&payout).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EarningEntry{
+			PayeeType: payout.PayeeType,
+			PayeeID:   payout.PayeeID,
+			Kind:      models.EarningKindPayout,
+			PayoutID:  &payout.ID,
+			Net:       -payout.Amount,
+		}).Error
+	})
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgPayoutNotFound)
+		return
+	case errors.Is(err, errPayoutNotPending):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgPayoutNotPending)
+		return
+	case errors.Is(err, errInsufficientEarnings):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgInsufficientEarnings)
+		return
+	case err != nil:
+		utils.LogError("Failed to review payout", map[string]interface{}{
+			"payout_id": c.Param("id"),
+			"error":     err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgPayoutReviewFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Payout %d %s by admin %d", payout.ID, payout.Status, admin.ID))
+	c.JSON(http.StatusOK, payout)
+}
+
+// ApprovePayout - approve a payout request and record it in the ledger
+// POST /admin/payouts/:id/approve
+func ApprovePayout(c *gin.Context) {
+	admin := c.MustGet("user").(models.User)
+	if admin.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+	reviewPayout(c, admin, true)
+}
+
+// RejectPayout - reject a payout request, the balance stays available
+// POST /admin/payouts/:id/reject
+func RejectPayout(c *gin.Context) {
+	admin := c.MustGet("user").(models.User)
+	if admin.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+	reviewPayout(c, admin, false)
+}
+
+// GetRevenueShares - platform fees agreed with payees, and the default one
+// GET /admin/revenue-shares
+func GetRevenueShares(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var shares []models.RevenueShare
+	if err := db.DB.Order("payee_type, payee_id").Find(&shares).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchEarningsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"defaultFeePercent": revenue.DefaultFeePercent(),
+		"shares":            shares,
+	})
+}
+
+// SetRevenueShare - set the platform fee of a developer or organization.
+// Applies to sales from now on.
+// PUT /admin/revenue-shares
+func SetRevenueShare(c *gin.Context) {
+	admin := c.MustGet("user").(models.User)
+	if admin.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var input models.RevenueShareInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	share := models.RevenueShare{
+		PayeeType:  input.PayeeType,
+		PayeeID:    input.PayeeID,
+		FeePercent: input.FeePercent,
+	}
+	err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "payee_type"}, {Name: "payee_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fee_percent", "updated_at"}),
+	}).Create(&share).Error
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveRevenueShareFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Platform fee of %s %d set to %.2f%% by admin %d", share.PayeeType, share.PayeeID, share.FeePercent, admin.ID))
+	c.JSON(http.StatusOK, share)
+}
+
+// DeleteRevenueShare - the payee goes back to the default fee
+// DELETE /admin/revenue-shares/:id
+func DeleteRevenueShare(c *gin.Context) {
+	admin := c.MustGet("user").(models.User)
+	if admin.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	result := db.DB.Delete(&models.RevenueShare{}, c.Param("id"))
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveRevenueShareFailed)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgRevenueShareNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Revenue share deleted"})
+}
@@ -0,0 +1,702 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	// familyMaxMembers counts the owner too
+	familyMaxMembers = 6
+	// familyLeaseTTL is how long a shared game stays taken without renewal
+	familyLeaseTTL = 15 * time.Minute
+)
+
+var (
+	errAlreadyInFamily = errors.New("user is already in a family group")
+	errFamilyFull      = errors.New("family group is full")
+)
+
+// InitFamilySharing overrides the family limits from FAMILY_MAX_MEMBERS and
+// FAMILY_LEASE_MINUTES
+func InitFamilySharing() error {
+	settings := []struct {
+		env   string
+		apply func(n int)
+	}{
+		{"FAMILY_MAX_MEMBERS", func(n int) { familyMaxMembers = n }},
+		{"FAMILY_LEASE_MINUTES", func(n int) { familyLeaseTTL = time.Duration(n) * time.Minute }},
+	}
+
+	for _, s := range settings {
+		value := os.Getenv(s.env)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid %s: %q", s.env, value)
+		}
+		s.apply(n)
+	}
+	return nil
+}
+
+// familyMemberView - a member as shown to the rest of the family
+type familyMemberView struct {
+	UserID        uint       `json:"userId"`
+	Name          string     `json:"name"`
+	Status        string     `json:"status"`
+	IsOwner       bool       `json:"isOwner"`
+	SharesLibrary bool       `json:"sharesLibrary"`
+	InvitedAt     time.Time  `json:"invitedAt"`
+	JoinedAt      *time.Time `json:"joinedAt,omitempty"`
+}
+
+// familyLender - who lent a shared game
+type familyLender struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// familyLeaseKey names the lease of one lent copy
+func familyLeaseKey(lenderID, gameID uint) string {
+	return fmt.Sprintf("family:%d:%d", lenderID, gameID)
+}
+
+// userFamily returns the group the user is an active member of
+func userFamily(tx *gorm.DB, userID uint) (models.FamilyGroup, bool, error) {
+	var member models.FamilyMember
+	err := tx.Where("user_id = ? AND status = ?", userID, models.FamilyMemberActive).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.FamilyGroup{}, false, nil
+	}
+	if err != nil {
+		return models.FamilyGroup{}, false, err
+	}
+
+	var group models.FamilyGroup
+	if err := tx.First(&group, member.GroupID).Error; err != nil {
+		return group, false, err
+	}
+	return group, true, nil
+}
+
+// requireFamily loads the user's family group or responds with an error
+func requireFamily(c *gin.Context, user models.User) (models.FamilyGroup, bool) {
+	group, found, err := userFamily(db.DB, user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchFamilyFailed)
+		return group, false
+	}
+	if !found {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgNotInFamily)
+		return group, false
+	}
+	return group, true
+}
+
+// familyLenderNames returns the active members other than userID who lend
+// their library, by ID
+func familyLenderNames(groupID, userID uint) (map[uint]string, error) {
+	var lenders []struct {
+		ID   uint
+		Name string
+	}
+	err := db.DB.Model(&models.FamilyMember{}).
+		Select("users.id, users.name").
+		Joins("JOIN users ON users.id = family_members.user_id").
+		Where("family_members.group_id = ? AND family_members.status = ? AND family_members.shares_library = ? AND family_members.user_id <> ?",
+			groupID, models.FamilyMemberActive, true, userID).
+		Scan(&lenders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uint]string, len(lenders))
+	for _, lender := range lenders {
+		names[lender.ID] = lender.Name
+	}
+	return names, nil
+}
+
+// sharedLibrary returns the games the user's family lends them, leaving out
+// games the user owns. A game lent by several members shows up once.
+func sharedLibrary(userID uint) ([]libraryEntry, error) {
+	group, found, err := userFamily(db.DB, userID)
+	if err != nil || !found {
+		return nil, err
+	}
+	lenders, err := familyLenderNames(group.ID, userID)
+	if err != nil || len(lenders) == 0 {
+		return nil, err
+	}
+	lenderIDs := make([]uint, 0, len(lenders))
+	for id := range lenders {
+		lenderIDs = append(lenderIDs, id)
+	}
+
+	var ownerships []models.Ownership
+	err = db.DB.Where("user_id IN ? AND status = ?", lenderIDs, "owned").
+		Where("game_id NOT IN (?)", db.DB.Model(&models.Ownership{}).Select("game_id").Where("user_id = ? AND status = ?", userID, "owned")).
+		Preload("Game").Order("game_id, user_id").Find(&ownerships).Error
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]libraryEntry, 0, len(ownerships))
+	seen := make(map[uint]bool, len(ownerships))
+	for _, ownership := range ownerships {
+		if seen[ownership.GameID] {
+			continue
+		}
+		seen[ownership.GameID] = true
+		entries = append(entries, libraryEntry{
+			Game:     ownership.Game,
+			SharedBy: &familyLender{ID: ownership.UserID, Name: lenders[ownership.UserID]},
+		})
+	}
+	return entries, nil
+}
+
+// dropCopyLeases ends the lease on one copy, e.g. once it is refunded
+func dropCopyLeases(lenderID, gameID uint) {
+	if err := cache.DropLeases(familyLeaseKey(lenderID, gameID), 0); err != nil {
+		utils.LogError("Failed to drop family lease", map[string]interface{}{
+			"lender_id": lenderID,
+			"game_id":   gameID,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// dropMemberLeases ends the leases of a member leaving the family: on their
+// own copies and on the copies they borrowed
+func dropMemberLeases(groupID, userID uint) {
+	var memberIDs []uint
+	db.DB.Model(&models.FamilyMember{}).Where("group_id = ?", groupID).Pluck("user_id", &memberIDs)
+
+	errs := []error{cache.DropLeases(fmt.Sprintf("family:%d:*", userID), 0)}
+	for _, id := range memberIDs {
+		if id != userID {
+			errs = append(errs, cache.DropLeases(fmt.Sprintf("family:%d:*", id), userID))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		utils.LogError("Failed to drop family leases", map[string]interface{}{
+			"group_id": groupID,
+			"user_id":  userID,
+			"error":    err.Error(),
+		})
+	}
+}
+
+// GetFamily - the user's family group with its members, and invitations
+// waiting for the user
+// GET /family
+func GetFamily(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var invitations []models.FamilyGroup
+	if err := db.DB.Where("id IN (?)", db.DB.Model(&models.FamilyMember{}).Select("group_id").
+		Where("user_id = ? AND status = ?", user.ID, models.FamilyMemberInvited)).
+		Find(&invitations).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchFamilyFailed)
+		return
+	}
+
+	group, found, err := userFamily(db.DB, user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchFamilyFailed)
+		return
+	}
+	if !found {
+		c.JSON(http.StatusOK, gin.H{"family": nil, "invitations": invitations})
+		return
+	}
+
+	var members []familyMemberView
+	if err := db.DB.Model(&models.FamilyMember{}).
+		Select("family_members.user_id, users.name, family_members.status, family_members.shares_library, family_members.invited_at, family_members.joined_at").
+		Joins("JOIN users ON users.id = family_members.user_id").
+		Where("family_members.group_id = ?", group.ID).
+		Order("family_members.id").
+		Scan(&members).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchFamilyFailed)
+		return
+	}
+	for i := range members {
+		members[i].IsOwner = members[i].UserID == group.OwnerID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"family":      group,
+		"members":     members,
+		"maxMembers":  familyMaxMembers,
+		"invitations": invitations,
+	})
+}
+
+// CreateFamily - start a family group with the current user as owner
+// POST /family
+func CreateFamily(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input models.FamilyGroupInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	group := models.FamilyGroup{OwnerID: user.ID, Name: input.Name}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if _, found, err := userFamily(tx, user.ID); err != nil || found {
+			if err == nil {
+				err = errAlreadyInFamily
+			}
+			return err
+		}
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Create(&models.FamilyMember{
+			GroupID:       group.ID,
+			UserID:        user.ID,
+			Status:        models.FamilyMemberActive,
+			SharesLibrary: true,
+			InvitedAt:     now,
+			JoinedAt:      &now,
+		}).Error
+	})
+	if errors.Is(err, errAlreadyInFamily) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgAlreadyInFamily)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateFamilyFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("User %d created family group %d", user.ID, group.ID))
+	c.JSON(http.StatusCreated, group)
+}
+
+// DisbandFamily - the owner ends the family group; all lending stops
+// DELETE /family
+func DisbandFamily(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	group, ok := requireFamily(c, user)
+	if !ok {
+		return
+	}
+	if group.OwnerID != user.ID {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgFamilyOwnersOnly)
+		return
+	}
+
+	var memberIDs []uint
+	db.DB.Model(&models.FamilyMember{}).Where("group_id = ?", group.ID).Pluck("user_id", &memberIDs)
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", group.ID).Delete(&models.FamilyMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&group).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateFamilyFailed)
+		return
+	}
+
+	for _, id := range memberIDs {
+		if err := cache.DropLeases(fmt.Sprintf("family:%d:*", id), 0); err != nil {
+			utils.LogError("Failed to drop family leases", map[string]interface{}{
+				"group_id": group.ID,
+				"error":    err.Error(),
+			})
+		}
+	}
+
+	utils.Log.Info(fmt.Sprintf("Family group %d disbanded by user %d", group.ID, user.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "Family group disbanded"})
+}
+
+// InviteFamilyMember - the owner invites a user by email
+// POST /family/members
+func InviteFamilyMember(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	group, ok := requireFamily(c, user)
+	if !ok {
+		return
+	}
+	if group.OwnerID != user.ID {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgFamilyOwnersOnly)
+		return
+	}
+
+	var input models.FamilyInviteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var invitee models.User
+	if err := db.DB.Where("email = ?", input.Email).First(&invitee).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgUserNotFound)
+		return
+	}
+	if invitee.ID == user.ID {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCannotInviteSelf)
+		return
+	}
+
+	member := models.FamilyMember{
+		GroupID:       group.ID,
+		UserID:        invitee.ID,
+		Status:        models.FamilyMemberInvited,
+		SharesLibrary: true,
+		InvitedAt:     time.Now(),
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, group.ID).Error; err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.FamilyMember{}).Where("group_id = ? AND user_id = ?", group.ID, invitee.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errAlreadyInFamily
+		}
+
+		var members int64
+		if err := tx.Model(&models.FamilyMember{}).Where("group_id = ?", group.ID).Count(&members).Error; err != nil {
+			return err
+		}
+		if members >= int64(familyMaxMembers) {
+			return errFamilyFull
+		}
+		return tx.Create(&member).Error
+	})
+	switch {
+	case errors.Is(err, errAlreadyInFamily):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgAlreadyInFamily)
+		return
+	case errors.Is(err, errFamilyFull):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgFamilyFull)
+		return
+	case err != nil:
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateFamilyFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("User %d invited user %d to family group %d", user.ID, invitee.ID, group.ID))
+	c.JSON(http.StatusCreated, member)
+}
+
+// AcceptFamilyInvite - join the family group that invited the user
+// POST /family/invitations/:id/accept
+func AcceptFamilyInvite(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var member models.FamilyMember
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var group models.FamilyGroup
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, c.Param("id")).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ? AND user_id = ? AND status = ?", group.ID, user.ID, models.FamilyMemberInvited).
+			First(&member).Error; err != nil {
+			return err
+		}
+		if _, found, err := userFamily(tx, user.ID); err != nil || found {
+			if err == nil {
+				err = errAlreadyInFamily
+			}
+			return err
+		}
+
+		var active int64
+		if err := tx.Model(&models.FamilyMember{}).
+			Where("group_id = ? AND status = ?", group.ID, models.FamilyMemberActive).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active >= int64(familyMaxMembers) {
+			return errFamilyFull
+		}
+
+		now := time.Now()
+		member.Status = models.FamilyMemberActive
+		member.JoinedAt = &now
+		return tx.Save(&member).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgFamilyInviteNotFound)
+		return
+	case errors.Is(err, errAlreadyInFamily):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgAlreadyInFamily)
+		return
+	case errors.Is(err, errFamilyFull):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgFamilyFull)
+		return
+	case err != nil:
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateFamilyFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("User %d joined family group %d", user.ID, member.GroupID))
+	c.JSON(http.StatusOK, member)
+}
+
+// DeclineFamilyInvite - turn down an invitation
+// POST /family/invitations/:id/decline
+func DeclineFamilyInvite(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	result := db.DB.Where("group_id = ? AND user_id = ? AND status = ?", c.Param("id"), user.ID, models.FamilyMemberInvited).
+		Delete(&models.FamilyMember{})
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateFamilyFailed)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgFamilyInviteNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined"})
+}
+
+// RemoveFamilyMember - the owner revokes a member's access or withdraws an
+// invitation; members may remove themselves to leave
+// DELETE /family/members/:userId
+func RemoveFamilyMember(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	group, ok := requireFamily(c, user)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidUserID)
+		return
+	}
+	if uint(memberID) != user.ID && group.OwnerID != user.ID {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgFamilyOwnersOnly)
+		return
+	}
+	if uint(memberID) == group.OwnerID {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgFamilyOwnerCannotLeave)
+		return
+	}
+
+	result := db.DB.Where("group_id = ? AND user_id = ?", group.ID, memberID).Delete(&models.FamilyMember{})
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateFamilyFailed)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgFamilyMemberNotFound)
+		return
+	}
+	dropMemberLeases(group.ID, uint(memberID))
+
+	utils.Log.Info(fmt.Sprintf("User %d removed from family group %d by user %d", memberID, group.ID, user.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "Family member removed"})
+}
+
+// SetFamilySharing - start or stop lending the own library. Stopping ends
+// the leases on the member's games right away.
+// PUT /family/sharing
+func SetFamilySharing(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	group, ok := requireFamily(c, user)
+	if !ok {
+		return
+	}
+
+	var input models.FamilySharingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	if err := db.DB.Model(&models.FamilyMember{}).
+		Where("group_id = ? AND user_id = ?", group.ID, user.ID).
+		Update("shares_library", *input.Enabled).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateFamilyFailed)
+		return
+	}
+	if !*input.Enabled {
+		if err := cache.DropLeases(fmt.Sprintf("family:%d:*", user.ID), 0); err != nil {
+			utils.LogError("Failed to drop family leases", map[string]interface{}{
+				"user_id": user.ID,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sharesLibrary": *input.Enabled})
+}
+
+// BorrowSharedGame - start playing a game lent by the family, or renew the
+// lease while playing. Each lent copy can be played by one member at a time.
+// Owners take the lease on their own copy the same way, and get it back
+// from a member who borrowed it.
+// POST /family/leases
+func BorrowSharedGame(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if !cache.IsRedisAvailable() {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, utils.MsgLeasesUnavailable)
+		return
+	}
+	group, ok := requireFamily(c, user)
+	if !ok {
+		return
+	}
+
+	var input models.FamilyLeaseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if ownedGameIDs(db.DB, user.ID, []uint{input.GameID})[input.GameID] {
+		playOwnCopy(c, user, input.GameID)
+		return
+	}
+
+	lenders, err := familyLenderNames(group.ID, user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchFamilyFailed)
+		return
+	}
+	lenderIDs := make([]uint, 0, len(lenders))
+	for id := range lenders {
+		if input.LenderID == nil || *input.LenderID == id {
+			lenderIDs = append(lenderIDs, id)
+		}
+	}
+
+	var owners []uint
+	if len(lenderIDs) > 0 {
+		if err := db.DB.Model(&models.Ownership{}).
+			Where("user_id IN ? AND game_id = ? AND status = ?", lenderIDs, input.GameID, "owned").
+			Order("user_id").Pluck("user_id", &owners).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchFamilyFailed)
+			return
+		}
+	}
+	if len(owners) == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgSharedGameNotFound)
+		return
+	}
+
+	// Renew a copy the user already plays before taking another one
+	for _, lenderID := range owners {
+		holder, _, err := cache.LeaseHolder(familyLeaseKey(lenderID, input.GameID))
+		if err == nil && holder == user.ID {
+			owners = []uint{lenderID}
+			break
+		}
+	}
+
+	for _, lenderID := range owners {
+		holder, err := cache.AcquireLease(familyLeaseKey(lenderID, input.GameID), user.ID, familyLeaseTTL)
+		if err != nil {
+			utils.LogError("Failed to acquire family lease", map[string]interface{}{
+				"user_id": user.ID,
+				"game_id": input.GameID,
+				"error":   err.Error(),
+			})
+			utils.ErrorResponse(c, http.StatusServiceUnavailable, utils.MsgLeasesUnavailable)
+			return
+		}
+		if holder != user.ID {
+			continue
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"gameId":    input.GameID,
+			"lender":    familyLender{ID: lenderID, Name: lenders[lenderID]},
+			"expiresAt": time.Now().Add(familyLeaseTTL),
+		})
+		return
+	}
+
+	utils.ErrorResponse(c, http.StatusConflict, utils.MsgSharedGameInUse)
+}
+
+// playOwnCopy takes the lease on the owner's copy of a game, ending the
+// lease of a member who borrowed it
+func playOwnCopy(c *gin.Context, owner models.User, gameID uint) {
+	previous, err := cache.TakeLease(familyLeaseKey(owner.ID, gameID), owner.ID, familyLeaseTTL)
+	if err != nil {
+		utils.LogError("Failed to take family lease", map[string]interface{}{
+			"user_id": owner.ID,
+			"game_id": gameID,
+			"error":   err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, utils.MsgLeasesUnavailable)
+		return
+	}
+
+	body := gin.H{
+		"gameId":    gameID,
+		"lender":    familyLender{ID: owner.ID, Name: owner.Name},
+		"expiresAt": time.Now().Add(familyLeaseTTL),
+	}
+	if previous != 0 && previous != owner.ID {
+		utils.Log.Info(fmt.Sprintf("User %d took game %d back from borrower %d", owner.ID, gameID, previous))
+		body["reclaimedFrom"] = previous
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// ReturnSharedGame - stop playing a borrowed or own copy so others can play it
+// DELETE /family/leases/:gameId
+func ReturnSharedGame(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	gameID, err := strconv.Atoi(c.Param("gameId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidGameID)
+		return
+	}
+	if err := cache.DropLeases(fmt.Sprintf("family:*:%d", gameID), user.ID); err != nil {
+		utils.ErrorResponse(c, http.StatusServiceUnavailable, utils.MsgLeasesUnavailable)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Shared game returned"})
+}
@@ -5,27 +5,56 @@ import (
 	"awesomeProject/db"
 	"awesomeProject/models"
 	"awesomeProject/utils"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"io"
 	"log"
 	"net/http"
+	"path"
 	"strconv"
+	"time"
 )
 
 // GetGames with Redis caching
+// categoryId accepts a category ID or slug; includeDescendants=true also lists
+// games of its subcategories. Optional filters: platform, ramMb and storageMb
+// (the caller's hardware, matched against minimum requirements).
+// Filtered requests bypass the cache.
 func GetGames(c *gin.Context) {
 	categoryID := c.Query("categoryId")
+	includeDescendants := c.Query("includeDescendants") == "true"
+	platform := c.Query("platform")
+	ramMB := c.Query("ramMb")
+	storageMB := c.Query("storageMb")
+
+	hasRequirementFilter := platform != "" || ramMB != "" || storageMB != ""
+
+	// Category may be given by ID or slug; the cache is keyed by ID
+	if categoryID != "" {
+		catID, _, err := resolveSlugRef(models.SlugEntityCategory, categoryID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, utils.MsgCategoryNotFound)
+			return
+		}
+		categoryID = strconv.Itoa(int(catID))
+	}
 
 	// Try cache first (only for non-filtered requests or specific category)
-	if cache.IsRedisAvailable() {
+	if cache.IsRedisAvailable() && !hasRequirementFilter {
 		var cachedGames interface{}
 		var err error
 
 		if categoryID != "" {
 			// Try category-specific cache
 			catID, _ := strconv.Atoi(categoryID)
-			cachedGames, err = cache.GetGamesByCategory(uint(catID))
+			if includeDescendants {
+				cachedGames, err = cache.GetGamesByCategorySubtree(uint(catID))
+			} else {
+				cachedGames, err = cache.GetGamesByCategory(uint(catID))
+			}
 		} else {
 			// Try all games cache
 			cachedGames, err = cache.GetGames()
@@ -41,22 +70,58 @@ func GetGames(c *gin.Context) {
 
 	// Fetch from database
 	var games []models.Game
-	query := db.DB.Preload("Category")
+	query := db.DB.Preload("Category").Preload("Platforms")
 
-	if categoryID != "" {
+	if categoryID != "" && includeDescendants {
+		catID, _ := strconv.Atoi(categoryID)
+		ids, err := categorySubtreeIDs(db.DB, uint(catID))
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGamesFailed)
+			return
+		}
+		query = query.Where("category_id IN ?", ids)
+	} else if categoryID != "" {
 		query = query.Where("category_id = ?", categoryID)
 	}
 
+	if hasRequirementFilter {
+		platforms := db.DB.Model(&models.GamePlatform{}).Select("game_id")
+		if platform != "" {
+			platforms = platforms.Where("platform = ?", platform)
+		}
+		if ramMB != "" {
+			ram, err := strconv.Atoi(ramMB)
+			if err != nil || ram < 0 {
+				utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidRequirementFilter)
+				return
+			}
+			platforms = platforms.Where("min_ram_mb <= ?", ram)
+		}
+		if storageMB != "" {
+			storage, err := strconv.Atoi(storageMB)
+			if err != nil || storage < 0 {
+				utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidRequirementFilter)
+				return
+			}
+			platforms = platforms.Where("min_storage_mb <= ?", storage)
+		}
+		query = query.Where("id IN (?)", platforms)
+	}
+
 	if err := query.Find(&games).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch games"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGamesFailed)
 		return
 	}
 
 	// Cache the result
-	if cache.IsRedisAvailable() {
+	if cache.IsRedisAvailable() && !hasRequirementFilter {
 		if categoryID != "" {
 			catID, _ := strconv.Atoi(categoryID)
-			cache.SetGamesByCategory(uint(catID), games)
+			if includeDescendants {
+				cache.SetGamesByCategorySubtree(uint(catID), games)
+			} else {
+				cache.SetGamesByCategory(uint(catID), games)
+			}
 		} else {
 			cache.SetGames(games)
 		}
@@ -66,11 +131,20 @@ func GetGames(c *gin.Context) {
 }
 
 // GetGameByID with Redis caching
+// Accepts a game ID or slug; old slugs redirect to the current one.
 func GetGameByID(c *gin.Context) {
 	id := c.Param("id")
-	gameID, err := strconv.Atoi(id)
+	gameID, currentSlug, err := resolveSlugRef(models.SlugEntityGame, id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
+		return
+	}
+	if currentSlug != "" {
+		location := path.Join(path.Dir(c.Request.URL.Path), currentSlug)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
 		return
 	}
 
@@ -87,8 +161,8 @@ func GetGameByID(c *gin.Context) {
 
 	// Fetch from database
 	var game models.Game
-	if err := db.DB.Preload("Category").First(&game, gameID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+	if err := db.DB.Preload("Category").Preload("Platforms").Preload("Organization").First(&game, gameID).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
 		return
 	}
 
@@ -104,7 +178,7 @@ func GetGameByID(c *gin.Context) {
 func CreateGame(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" && user.Role != "developer" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins or developers only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOrDevelopers)
 		return
 	}
 
@@ -115,49 +189,90 @@ func CreateGame(c *gin.Context) {
 	developerIDStr := c.PostForm("developerId")
 
 	if name == "" || priceStr == "" || categoryIDStr == "" || developerIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing required fields"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgMissingRequiredFields)
 		return
 	}
 
 	price, err := strconv.ParseFloat(priceStr, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidPrice)
 		return
 	}
 	categoryID, err := strconv.Atoi(categoryIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidCategoryID)
 		return
 	}
 	developerID, err := strconv.Atoi(developerIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid developer ID"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidDeveloperID)
+		return
+	}
+
+	organizationID, code := resolveGameOrganization(user, c.PostForm("organization_id"))
+	if code != "" {
+		utils.ErrorResponse(c, http.StatusForbidden, code)
+		return
+	}
+
+	gameType := c.DefaultPostForm("type", models.GameTypeGame)
+	baseGameID, code := resolveBaseGame(gameType, c.PostForm("base_game_id"), 0)
+	if code != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, code)
+		return
+	}
+
+	releaseDate, ok := parseReleaseDate(c.PostForm("release_date"))
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidReleaseDate)
 		return
 	}
 
 	file, err := c.FormFile("image")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image is required"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgImageRequired)
 		return
 	}
 
 	filePath := fmt.Sprintf("uploads/%s", file.Filename)
 	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveImageFailed)
+		return
+	}
+
+	slug, err := pickSlug(db.DB, models.SlugEntityGame, 0, c.PostForm("slug"), name)
+	if code := slugErrorCode(err); code != "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, code)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCreateGameFailed)
 		return
 	}
 
 	game := models.Game{
 		Name:        name,
+		Slug:        slug,
 		Price:       price,
 		Description: description,
 		CategoryID:  uint(categoryID),
 		DeveloperID: uint(developerID),
 		Image:       filePath,
+		Type:        gameType,
+		BaseGameID:  baseGameID,
+
+		OrganizationID: organizationID,
+		ReleaseDate:    releaseDate,
 	}
 
-	if err := db.DB.Create(&game).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create game"})
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&game).Error; err != nil {
+			return err
+		}
+		return recordGameRevision(tx, user, models.RevisionCreate, models.GameSnapshot{}, game, nil)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCreateGameFailed)
 		return
 	}
 
@@ -178,19 +293,36 @@ func UpdateGame(c *gin.Context) {
 
 	var game models.Game
 	if err := db.DB.First(&game, gameID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
 		return
 	}
 
 	user := c.MustGet("user").(models.User)
-	if user.Role != "admin" && (user.Role != "developer" || user.ID != game.DeveloperID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	if !canManageGame(user, game) {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
 		return
 	}
+	before := models.SnapshotGame(game)
 
 	name := c.PostForm("name")
 	price := c.PostForm("price")
 	description := c.PostForm("description")
+	requestedSlug := c.PostForm("slug")
+
+	// A rename regenerates the slug unless one is given explicitly
+	oldSlug := game.Slug
+	if requestedSlug != "" || (name != "" && name != game.Name) {
+		slug, err := pickSlug(db.DB, models.SlugEntityGame, game.ID, requestedSlug, name)
+		if code := slugErrorCode(err); code != "" {
+			utils.ErrorResponse(c, http.StatusBadRequest, code)
+			return
+		}
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateGameFailed)
+			return
+		}
+		game.Slug = slug
+	}
 
 	if name != "" {
 		game.Name = name
@@ -204,25 +336,136 @@ func UpdateGame(c *gin.Context) {
 		}
 	}
 
+	// Sale: discount_percent, and discount_ends_at (RFC 3339, empty clears it)
+	if percent := c.PostForm("discount_percent"); percent != "" {
+		parsed, err := strconv.Atoi(percent)
+		if err != nil || parsed < 0 || parsed > 100 {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidDiscount)
+			return
+		}
+		game.DiscountPercent = parsed
+	}
+	if endsAt, ok := c.GetPostForm("discount_ends_at"); ok {
+		game.DiscountEndsAt = nil
+		if endsAt != "" {
+			parsed, err := time.Parse(time.RFC3339, endsAt)
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidDiscount)
+				return
+			}
+			game.DiscountEndsAt = &parsed
+		}
+	}
+	if value, ok := c.GetPostForm("release_date"); ok {
+		releaseDate, valid := parseReleaseDate(value)
+		if !valid {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidReleaseDate)
+			return
+		}
+		game.ReleaseDate = releaseDate
+	}
+
+	// Move the game to another organization
+	if orgIDStr := c.PostForm("organization_id"); orgIDStr != "" {
+		organizationID, code := resolveGameOrganization(user, orgIDStr)
+		if code != "" {
+			utils.ErrorResponse(c, http.StatusForbidden, code)
+			return
+		}
+		game.OrganizationID = organizationID
+	}
+
+	// Add-on type and base game
+	if gameType, baseID := c.PostForm("type"), c.PostForm("base_game_id"); gameType != "" || baseID != "" {
+		if gameType == "" {
+			gameType = game.Type
+		}
+		if baseID == "" && game.BaseGameID != nil && gameType != models.GameTypeGame {
+			baseID = strconv.Itoa(int(*game.BaseGameID))
+		}
+		baseGameID, code := resolveBaseGame(gameType, baseID, game.ID)
+		if code != "" {
+			utils.ErrorResponse(c, http.StatusBadRequest, code)
+			return
+		}
+		game.Type = gameType
+		game.BaseGameID = baseGameID
+	}
+
+	// Platforms and system requirements (JSON list, replaces existing)
+	var platformsInput *models.GamePlatformsInput
+	if raw := c.PostForm("platforms"); raw != "" {
+		platformsInput = &models.GamePlatformsInput{}
+		if err := json.Unmarshal([]byte(raw), &platformsInput.Platforms); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidPlatforms)
+			return
+		}
+		if err := utils.ValidateStruct(platformsInput); err != nil {
+			utils.ValidationErrorResponse(c, err)
+			return
+		}
+		seen := make(map[string]bool)
+		for _, p := range platformsInput.Platforms {
+			if seen[p.Platform] {
+				utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgDuplicatePlatform)
+				return
+			}
+			seen[p.Platform] = true
+		}
+	}
+
 	file, err := c.FormFile("image")
 	if err == nil {
 		imagePath := "uploads/" + file.Filename
 		if err := c.SaveUploadedFile(file, imagePath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image"})
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveImageFailed)
 			return
 		}
 		game.Image = imagePath
 	}
 
-	if err := db.DB.Save(&game).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update game"})
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Platforms").Save(&game).Error; err != nil {
+			return err
+		}
+		if err := moveSlug(tx, models.SlugEntityGame, game.ID, oldSlug, game.Slug); err != nil {
+			return err
+		}
+		if err := recordGameRevision(tx, user, models.RevisionUpdate, before, game, nil); err != nil {
+			return err
+		}
+		if platformsInput == nil {
+			return nil
+		}
+		if err := tx.Where("game_id = ?", game.ID).Delete(&models.GamePlatform{}).Error; err != nil {
+			return err
+		}
+		for _, p := range platformsInput.Platforms {
+			platform := models.GamePlatform{
+				GameID:      game.ID,
+				Platform:    p.Platform,
+				Minimum:     p.Minimum,
+				Recommended: p.Recommended,
+			}
+			if err := tx.Create(&platform).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateGameFailed)
 		return
 	}
 
+	db.DB.Preload("Category").Preload("Platforms").First(&game, game.ID)
+	notifyPriceDrop(before, game)
+
 	// Invalidate caches
 	if cache.IsRedisAvailable() {
 		cache.InvalidateGame(uint(gameID))
 		cache.InvalidateGamesList()
+		cache.InvalidateSlug(models.SlugEntityGame, oldSlug)
 		utils.Log.Info(fmt.Sprintf("Cache invalidated for game %d", gameID))
 	}
 
@@ -239,22 +482,23 @@ func DeleteGame(c *gin.Context) {
 	var game models.Game
 	if err := db.DB.First(&game, gameID).Error; err != nil {
 		log.Printf("Game not found: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
 		return
 	}
 
 	user := c.MustGet("user").(models.User)
 	log.Printf("User ID: %d, Role: %s", user.ID, user.Role)
 
-	if user.Role != "admin" && user.Role != "developer" {
-		log.Printf("Access denied for role: %s", user.Role)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins or developers only"})
+	if !canManageGame(user, game) {
+		log.Printf("User %d cannot delete game %s (developer %d)", user.ID, id, game.DeveloperID)
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgOwnGamesOnly)
 		return
 	}
 
-	if user.Role == "developer" && game.DeveloperID != user.ID {
-		log.Printf("Developer %d cannot delete game %s (owned by %d)", user.ID, id, game.DeveloperID)
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own games"})
+	var addOns int64
+	db.DB.Model(&models.Game{}).Where("base_game_id = ?", gameID).Count(&addOns)
+	if addOns > 0 {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGameHasAddOns)
 		return
 	}
 
@@ -267,6 +511,38 @@ func DeleteGame(c *gin.Context) {
 			log.Printf("Failed to delete reviews: %v", err)
 			return err
 		}
+		if err := tx.Where("game_id = ?", gameID).Delete(&models.GamePlatform{}).Error; err != nil {
+			log.Printf("Failed to delete platforms: %v", err)
+			return err
+		}
+		if err := tx.Where("game_id = ?", gameID).Delete(&models.SeriesEntry{}).Error; err != nil {
+			log.Printf("Failed to remove game from series: %v", err)
+			return err
+		}
+		if err := tx.Where("game_id = ?", gameID).Delete(&models.CartItem{}).Error; err != nil {
+			log.Printf("Failed to remove game from carts: %v", err)
+			return err
+		}
+		if err := tx.Exec("DELETE FROM bundle_games WHERE game_id = ?", gameID).Error; err != nil {
+			log.Printf("Failed to remove game from bundles: %v", err)
+			return err
+		}
+		if err := tx.Where("entity = ? AND target_id = ?", models.SlugEntityGame, gameID).Delete(&models.SlugRedirect{}).Error; err != nil {
+			log.Printf("Failed to delete slug redirects: %v", err)
+			return err
+		}
+		if err := tx.Where("game_id = ?", gameID).Delete(&models.GameRevision{}).Error; err != nil {
+			log.Printf("Failed to delete revisions: %v", err)
+			return err
+		}
+		if err := tx.Where("game_id = ?", gameID).Delete(&models.ActivationKey{}).Error; err != nil {
+			log.Printf("Failed to delete activation keys: %v", err)
+			return err
+		}
+		if err := tx.Where("game_id = ?", gameID).Delete(&models.KeyBatch{}).Error; err != nil {
+			log.Printf("Failed to delete key batches: %v", err)
+			return err
+		}
 		if err := tx.Delete(&game).Error; err != nil {
 			log.Printf("Failed to delete game: %v", err)
 			return err
@@ -276,7 +552,7 @@ func DeleteGame(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Transaction failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete game: " + err.Error()})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgDeleteGameFailed)
 		return
 	}
 
@@ -285,6 +561,7 @@ func DeleteGame(c *gin.Context) {
 		cache.InvalidateGame(uint(gameID))
 		cache.InvalidateGamesList()
 		cache.InvalidateReviews(uint(gameID))
+		cache.InvalidateSlug(models.SlugEntityGame, game.Slug)
 		cache.InvalidateDashboardStats()
 		utils.Log.Info(fmt.Sprintf("All caches invalidated for deleted game %d", gameID))
 	}
@@ -293,32 +570,156 @@ func DeleteGame(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Game deleted"})
 }
 
-// ReturnGame with cache invalidation
+// resolveGameOrganization checks that the user may publish games under the organization
+func resolveGameOrganization(user models.User, orgIDStr string) (*uint, utils.MessageCode) {
+	if orgIDStr == "" {
+		return nil, ""
+	}
+	orgID, err := strconv.Atoi(orgIDStr)
+	if err != nil || orgID <= 0 {
+		return nil, utils.MsgInvalidOrganizationID
+	}
+	var org models.Organization
+	if err := db.DB.First(&org, orgID).Error; err != nil {
+		return nil, utils.MsgOrganizationNotFound
+	}
+	if !hasOrgRole(user, org.ID, models.OrgRoleOwner, models.OrgRoleEditor) {
+		return nil, utils.MsgOrgEditorsOnly
+	}
+	return &org.ID, ""
+}
+
+// resolveBaseGame validates a game type and its base game.
+// gameID is the game being edited, or 0 for a new game.
+func resolveBaseGame(gameType, baseGameIDStr string, gameID uint) (*uint, utils.MessageCode) {
+	switch gameType {
+	case models.GameTypeGame:
+		if baseGameIDStr != "" {
+			return nil, utils.MsgBaseGameNotAllowed
+		}
+		return nil, ""
+	case models.GameTypeDLC, models.GameTypeSoundtrack, models.GameTypeExpansion:
+	default:
+		return nil, utils.MsgInvalidGameType
+	}
+
+	if baseGameIDStr == "" {
+		return nil, utils.MsgBaseGameRequired
+	}
+	baseID, err := strconv.Atoi(baseGameIDStr)
+	if err != nil || baseID <= 0 || uint(baseID) == gameID {
+		return nil, utils.MsgInvalidBaseGame
+	}
+
+	var base models.Game
+	if err := db.DB.First(&base, baseID).Error; err != nil {
+		return nil, utils.MsgBaseGameNotFound
+	}
+	// Add-ons can't have add-ons of their own
+	if base.IsAddOn() {
+		return nil, utils.MsgInvalidBaseGame
+	}
+	if gameID != 0 {
+		var addOns int64
+		db.DB.Model(&models.Game{}).Where("base_game_id = ?", gameID).Count(&addOns)
+		if addOns > 0 {
+			return nil, utils.MsgGameHasAddOns
+		}
+	}
+
+	return &base.ID, ""
+}
+
+// parseReleaseDate reads a release date given as YYYY-MM-DD or RFC 3339;
+// empty means no date
+func parseReleaseDate(value string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed, true
+		}
+	}
+	return nil, false
+}
+
+// ReturnGame requests a refund. Requests within the refund policy are
+// approved right away, others are queued for admin review. Refunded games
+// keep their ownership row with status "refunded".
+// Wishlist entries are simply removed.
 func ReturnGame(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
-	gameID := c.Query("gameId")
 
-	if gameID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "gameId is required"})
+	if c.Query("gameId") == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgGameIDRequired)
+		return
+	}
+	gameID, err := strconv.Atoi(c.Query("gameId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidGameID)
 		return
 	}
 
 	var ownership models.Ownership
 	if err := db.DB.Where("user_id = ? AND game_id = ?", user.ID, gameID).First(&ownership).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Ownership not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOwnershipNotFound)
 		return
 	}
 
-	if err := db.DB.Delete(&ownership).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ownership"})
+	if ownership.Status == "wishlisted" {
+		if err := removeWishlistEntry(db.DB, ownership); err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgDeleteOwnershipFail)
+			return
+		}
+
+		// Invalidate user's library cache
+		if cache.IsRedisAvailable() {
+			cache.InvalidateUserLibrary(user.ID)
+			utils.Log.Info(fmt.Sprintf("Library cache invalidated for user %d", user.ID))
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Game returned successfully"})
 		return
 	}
 
-	// Invalidate user's library cache
-	if cache.IsRedisAvailable() {
-		cache.InvalidateUserLibrary(user.ID)
-		utils.Log.Info(fmt.Sprintf("Library cache invalidated for user %d", user.ID))
+	var input models.RefundRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	request, decision, err := requestRefund(user, uint(gameID), input.Reason)
+	if errors.Is(err, errRefundAlreadyRequested) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgRefundAlreadyRequested)
+		return
+	}
+	if err != nil {
+		refundErrorResponse(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Game returned successfully"})
+	if !decision.AutoApprove {
+		utils.Log.Info(fmt.Sprintf("Refund %d of game %d by user %d queued for review: %s", request.ID, gameID, user.ID, request.PolicyViolations))
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Refund request queued for review",
+			"refund":  request,
+		})
+		return
+	}
+
+	request, err = approveRefund(request.ID, nil, "")
+	if err != nil {
+		refundErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Game returned successfully",
+		"refund":  request,
+	})
 }
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"time"
+)
+
+var (
+	errGiftNotPending = errors.New("gift already answered")
+	errGiftOwned      = errors.New("recipient already owns the game")
+)
+
+// resolveGiftRecipient finds the user a purchase is for, by ID or email
+func resolveGiftRecipient(c *gin.Context, sender models.User, input models.BuyGameInput) (models.User, bool) {
+	var recipient models.User
+	query := db.DB
+	if input.RecipientID != nil {
+		query = query.Where("id = ?", *input.RecipientID)
+	} else {
+		query = query.Where("email = ?", input.RecipientEmail)
+	}
+	if err := query.First(&recipient).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgRecipientNotFound)
+		return recipient, false
+	}
+	if recipient.ID == sender.ID {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgCannotGiftSelf)
+		return recipient, false
+	}
+	return recipient, true
+}
+
+// pendingGiftGameIDs returns which of the games are already on their way to
+// the recipient: unanswered gifts or gift orders still being paid
+func pendingGiftGameIDs(tx *gorm.DB, recipientID uint, gameIDs []uint) map[uint]bool {
+	var ids []uint
+	tx.Model(&models.Gift{}).
+		Where("recipient_id = ? AND status = ? AND game_id IN ?", recipientID, models.GiftStatusPending, gameIDs).
+		Pluck("game_id", &ids)
+
+	var ordered []uint
+	tx.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.gift_recipient_id = ? AND orders.status = ? AND order_items.game_id IN ?", recipientID, models.OrderStatusPending, gameIDs).
+		Pluck("order_items.game_id", &ordered)
+
+	pending := make(map[uint]bool, len(ids)+len(ordered))
+	for _, id := range append(ids, ordered...) {
+		pending[id] = true
+	}
+	return pending
+}
+
+// sendGifts puts the games of a paid gift order into the recipient's inbox
+func sendGifts(tx *gorm.DB, order models.Order) error {
+	var sender models.User
+	if err := tx.Select("id", "name").First(&sender, order.UserID).Error; err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		gift := models.Gift{
+			OrderID:     order.ID,
+			SenderID:    sender.ID,
+			SenderName:  sender.Name,
+			RecipientID: *order.GiftRecipientID,
+			GameID:      item.GameID,
+			Message:     order.GiftMessage,
+			Status:      models.GiftStatusPending,
+		}
+		if err := tx.Create(&gift).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// giftErrorResponse maps gift errors to responses
+func giftErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGiftNotFound)
+	case errors.Is(err, errGiftNotPending):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGiftNotPending)
+	case errors.Is(err, errGiftOwned):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGameAlreadyOwned)
+	default:
+		utils.LogError("Gift response failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgGiftResponseFailed)
+	}
+}
+
+// GetGifts - the current user's gift inbox, newest first
+// GET /gifts?status=pending
+func GetGifts(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	query := db.DB.Where("recipient_id = ?", user.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var gifts []models.Gift
+	if err := query.Preload("Game").Order("id DESC").Find(&gifts).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGiftsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, gifts)
+}
+
+// GetSentGifts - gifts the current user sent, newest first
+// GET /gifts/sent
+func GetSentGifts(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var gifts []models.Gift
+	if err := db.DB.Where("sender_id = ?", user.ID).Preload("Game").Order("id DESC").Find(&gifts).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGiftsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, gifts)
+}
+
+// lockPendingGift loads one of the user's unanswered gifts for update
+func lockPendingGift(tx *gorm.DB, recipientID uint, giftID string) (models.Gift, error) {
+	var gift models.Gift
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("recipient_id = ?", recipientID).First(&gift, giftID).Error; err != nil {
+		return gift, err
+	}
+	if gift.Status != models.GiftStatusPending {
+		return gift, errGiftNotPending
+	}
+	return gift, nil
+}
+
+// AcceptGift - add a gifted game to the library
+// POST /gifts/:id/accept
+func AcceptGift(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var gift models.Gift
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if gift, err = lockPendingGift(tx, user.ID, c.Param("id")); err != nil {
+			return err
+		}
+
+		var owned int64
+		if err := tx.Model(&models.Ownership{}).
+			Where("user_id = ? AND game_id = ? AND status = ?", user.ID, gift.GameID, "owned").
+			Count(&owned).Error; err != nil {
+			return err
+		}
+		if owned > 0 {
+			return errGiftOwned
+		}
+
+		if err := grantOwnership(tx, user.ID, gift.GameID, models.OwnershipSourceGift, &gift.ID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		gift.Status = models.GiftStatusAccepted
+		gift.RespondedAt = &now
+		return tx.Omit("Game").Save(&gift).Error
+	})
+	if err != nil {
+		giftErrorResponse(c, err)
+		return
+	}
+
+	if cache.IsRedisAvailable() {
+		cache.InvalidateUserLibrary(user.ID)
+		utils.Log.Info(fmt.Sprintf("Library cache invalidated for user %d after accepting gift %d", user.ID, gift.ID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Gift accepted", "gift": gift})
+}
+
+// DeclineGift - turn down a gift; the sender gets the money back
+// POST /gifts/:id/decline
+func DeclineGift(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var gift models.Gift
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if gift, err = lockPendingGift(tx, user.ID, c.Param("id")); err != nil {
+			return err
+		}
+
+		order, amount, err := refundOrderItem(tx, gift.OrderID, gift.GameID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		gift.Status = models.GiftStatusDeclined
+		gift.RespondedAt = &now
+		if err := tx.Omit("Game").Save(&gift).Error; err != nil {
+			return err
+		}
+
+		// Last, so a failed refund keeps the gift in the inbox
+		return refundPayment(tx, order, amount)
+	})
+	if err != nil {
+		giftErrorResponse(c, err)
+		return
+	}
+
+	if cache.IsRedisAvailable() {
+		cache.InvalidateDashboardStats()
+	}
+
+	utils.Log.Info(fmt.Sprintf("Gift %d declined by user %d, sender %d refunded", gift.ID, user.ID, gift.SenderID))
+	c.JSON(http.StatusOK, gin.H{"message": "Gift declined", "gift": gift})
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyAlphabet leaves out characters that are easy to mix up (0/O, 1/I)
+const (
+	keyAlphabet    = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+	keyGroups      = 5
+	keyGroupLength = 5
+)
+
+var (
+	errKeyInvalid   = errors.New("activation key not found")
+	errKeyRedeemed  = errors.New("activation key already redeemed")
+	errKeyExpired   = errors.New("activation key expired")
+	errKeyExhausted = errors.New("key batch exhausted")
+	errKeyGameOwned = errors.New("game already owned")
+	errKeyNoBase    = errors.New("base game not owned")
+)
+
+// newActivationKey returns a random key like ABCDE-FGHJK-LMNPQ-RSTUV-WXYZ2
+func newActivationKey() (string, error) {
+	max := big.NewInt(int64(len(keyAlphabet)))
+	groups := make([]string, keyGroups)
+	for g := range groups {
+		group := make([]byte, keyGroupLength)
+		for i := range group {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			group[i] = keyAlphabet[n.Int64()]
+		}
+		groups[g] = string(group)
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// normalizeActivationKey accepts keys typed in any case, with or without
+// dashes and spaces
+func normalizeActivationKey(key string) string {
+	key = strings.ToUpper(key)
+	key = strings.NewReplacer("-", "", " ", "").Replace(key)
+	if len(key) != keyGroups*keyGroupLength {
+		return key
+	}
+	groups := make([]string, keyGroups)
+	for g := range groups {
+		groups[g] = key[g*keyGroupLength : (g+1)*keyGroupLength]
+	}
+	return strings.Join(groups, "-")
+}
+
+// CreateKeyBatch - generate a batch of activation keys for a game
+// POST /games/:id/key-batches
+func CreateKeyBatch(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	game, ok := loadManagedGame(c, user)
+	if !ok {
+		return
+	}
+
+	var input models.KeyBatchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidKeyExpiry)
+		return
+	}
+
+	batch := models.KeyBatch{
+		GameID:         game.ID,
+		Label:          input.Label,
+		CreatedByID:    user.ID,
+		Count:          input.Count,
+		MaxRedemptions: input.MaxRedemptions,
+		ExpiresAt:      input.ExpiresAt,
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+
+		keys := make([]models.ActivationKey, batch.Count)
+		for i := range keys {
+			code, err := newActivationKey()
+			if err != nil {
+				return err
+			}
+			keys[i] = models.ActivationKey{BatchID: batch.ID, GameID: game.ID, Code: code}
+		}
+		return tx.Omit("Game").CreateInBatches(&keys, 500).Error
+	})
+	if err != nil {
+		utils.LogError("Failed to generate activation keys", map[string]interface{}{
+			"game_id": game.ID,
+			"error":   err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgGenerateKeysFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("User %d generated %d keys for game %d (batch %d)", user.ID, batch.Count, game.ID, batch.ID))
+	c.JSON(http.StatusCreated, batch)
+}
+
+// GetKeyBatches - key batches of a game with their redemption counts
+// GET /games/:id/key-batches
+func GetKeyBatches(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	game, ok := loadManagedGame(c, user)
+	if !ok {
+		return
+	}
+
+	var batches []models.KeyBatch
+	if err := db.DB.Where("game_id = ?", game.ID).Order("id DESC").Find(&batches).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchKeysFailed)
+		return
+	}
+	c.JSON(http.StatusOK, batches)
+}
+
+// DownloadKeyBatch - the keys of a batch as CSV, with who redeemed them
+// GET /games/:id/key-batches/:batchId/keys
+func DownloadKeyBatch(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	game, ok := loadManagedGame(c, user)
+	if !ok {
+		return
+	}
+
+	var batch models.KeyBatch
+	if err := db.DB.Where("game_id = ?", game.ID).First(&batch, c.Param("batchId")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgKeyBatchNotFound)
+		return
+	}
+
+	var keys []models.ActivationKey
+	if err := db.DB.Where("batch_id = ?", batch.ID).Order("id").Find(&keys).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchKeysFailed)
+		return
+	}
+
+	filename := fmt.Sprintf("keys-%s-batch-%d.csv", game.Slug, batch.ID)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"key", "redeemed_by", "redeemed_at"})
+	for _, key := range keys {
+		row := []string{key.Code, "", ""}
+		if key.RedeemedByID != nil {
+			row[1] = strconv.FormatUint(uint64(*key.RedeemedByID), 10)
+			row[2] = key.RedeemedAt.UTC().Format(time.RFC3339)
+		}
+		writer.Write(row)
+	}
+	writer.Flush()
+}
+
+// RedeemKey - exchange an activation key for the game. The key and its
+// batch are locked, so a key can only ever be redeemed once.
+// POST /redeem
+func RedeemKey(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input models.RedeemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var key models.ActivationKey
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("code = ?", normalizeActivationKey(input.Key)).First(&key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errKeyInvalid
+		}
+		if err != nil {
+			return err
+		}
+		if key.RedeemedByID != nil {
+			return errKeyRedeemed
+		}
+
+		var batch models.KeyBatch
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, key.BatchID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if batch.ExpiresAt != nil && now.After(*batch.ExpiresAt) {
+			return errKeyExpired
+		}
+		if batch.MaxRedemptions != nil && batch.Redeemed >= *batch.MaxRedemptions {
+			return errKeyExhausted
+		}
+
+		// Keys of games the user already has stay unused. The user is locked
+		// like in checkout, so a concurrent purchase can't slip in between.
+		if err := tx.First(&key.Game, key.GameID).Error; err != nil {
+			return err
+		}
+		if err := lockOrderOwner(tx, models.Order{UserID: user.ID}); err != nil {
+			return err
+		}
+		if ownedGameIDs(tx, user.ID, []uint{key.GameID})[key.GameID] {
+			return errKeyGameOwned
+		}
+		if key.Game.IsAddOn() && !ownedGameIDs(tx, user.ID, []uint{*key.Game.BaseGameID})[*key.Game.BaseGameID] {
+			return errKeyNoBase
+		}
+
+		if err := grantOwnership(tx, user.ID, key.GameID, models.OwnershipSourceKey, nil); err != nil {
+			return err
+		}
+		key.RedeemedByID = &user.ID
+		key.RedeemedAt = &now
+		if err := tx.Omit("Game").Save(&key).Error; err != nil {
+			return err
+		}
+		return tx.Model(&batch).Update("redeemed", gorm.Expr("redeemed + 1")).Error
+	})
+
+	switch {
+	case errors.Is(err, errKeyInvalid):
+		utils.LogWarn("Invalid activation key", map[string]interface{}{
+			"user_id": user.ID,
+			"ip":      c.ClientIP(),
+		})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgInvalidActivationKey)
+		return
+	case errors.Is(err, errKeyRedeemed):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgKeyAlreadyRedeemed)
+		return
+	case errors.Is(err, errKeyExpired):
+		utils.ErrorResponse(c, http.StatusGone, utils.MsgKeyExpired)
+		return
+	case errors.Is(err, errKeyExhausted):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgKeyBatchExhausted)
+		return
+	case errors.Is(err, errKeyGameOwned):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGameAlreadyOwned)
+		return
+	case errors.Is(err, errKeyNoBase):
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgBaseGameNotOwned)
+		return
+	case err != nil:
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgRedeemFailed)
+		return
+	}
+
+	if cache.IsRedisAvailable() {
+		cache.InvalidateUserLibrary(user.ID)
+		utils.Log.Info(fmt.Sprintf("Library cache invalidated for user %d after redeeming key %d", user.ID, key.ID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Key redeemed",
+		"game":    key.Game,
+	})
+}
+
+// GetRedeemedKeys - keys the current user redeemed, newest first
+// GET /redeem
+func GetRedeemedKeys(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var keys []models.ActivationKey
+	if err := db.DB.Where("redeemed_by_id = ?", user.ID).Preload("Game").Order("redeemed_at DESC").Find(&keys).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchKeysFailed)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeActivationKey(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"ABCDE-FGHJK-LMNPQ-RSTUV-WXYZ2", "ABCDE-FGHJK-LMNPQ-RSTUV-WXYZ2"},
+		{"abcde-fghjk-lmnpq-rstuv-wxyz2", "ABCDE-FGHJK-LMNPQ-RSTUV-WXYZ2"},
+		{"ABCDEFGHJKLMNPQRSTUVWXYZ2", "ABCDE-FGHJK-LMNPQ-RSTUV-WXYZ2"},
+		{" abcde fghjk lmnpq rstuv wxyz2 ", "ABCDE-FGHJK-LMNPQ-RSTUV-WXYZ2"},
+		{"AB-CDEFG-HJKLM-NPQRS-TUVWX-YZ2", "ABCDE-FGHJK-LMNPQ-RSTUV-WXYZ2"},
+		{"abc-def", "ABCDEF"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeActivationKey(tt.key); got != tt.want {
+			t.Errorf("normalizeActivationKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestNewActivationKey(t *testing.T) {
+	key, err := newActivationKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalizeActivationKey(key) != key {
+		t.Errorf("generated key %q is not in normal form", key)
+	}
+	for _, r := range strings.ReplaceAll(key, "-", "") {
+		if !strings.ContainsRune(keyAlphabet, r) {
+			t.Errorf("generated key %q uses %q outside the alphabet", key, r)
+		}
+	}
+}
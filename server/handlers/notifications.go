@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetNotifications - the current user's notifications, newest first
+// GET /notifications?unread=true&limit=50
+func GetNotifications(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := db.DB.Where("user_id = ?", user.ID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("id DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchNotificationsFailed)
+		return
+	}
+
+	var unread int64
+	db.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", user.ID).Count(&unread)
+
+	c.JSON(http.StatusOK, gin.H{
+		"unread":        unread,
+		"notifications": notifications,
+	})
+}
+
+// MarkNotificationRead - mark one notification read
+// POST /notifications/:id/read
+func MarkNotificationRead(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var notification models.Notification
+	if err := db.DB.Where("user_id = ?", user.ID).First(&notification, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgNotificationNotFound)
+		return
+	}
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := db.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchNotificationsFailed)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead - mark every unread notification read
+// POST /notifications/read
+func MarkAllNotificationsRead(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	result := db.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", user.ID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchNotificationsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
@@ -0,0 +1,494 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/payments"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+var errChargeFailed = errors.New("payment provider rejected the charge")
+
+// purchaseConflict - a game of the order got owned or ordered by a
+// concurrent checkout
+type purchaseConflict struct {
+	code utils.MessageCode
+}
+
+func (e purchaseConflict) Error() string {
+	return "purchase conflict: " + string(e.code)
+}
+
+// orderItemsFor snapshots the games' names and current prices, with
+// active discounts applied, into order items
+func orderItemsFor(games []models.Game) []models.OrderItem {
+	now := time.Now()
+	items := make([]models.OrderItem, len(games))
+	for i, game := range games {
+		items[i] = models.OrderItem{
+			GameID:    game.ID,
+			Name:      game.Name,
+			ListPrice: game.Price,
+			Price:     game.EffectivePrice(now),
+		}
+	}
+	return items
+}
+
+// pendingOrderGameIDs returns which of the games the user is already paying for
+func pendingOrderGameIDs(tx *gorm.DB, userID uint, gameIDs []uint) map[uint]bool {
+	var ids []uint
+	tx.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND orders.status = ? AND orders.gift_recipient_id IS NULL AND order_items.game_id IN ?", userID, models.OrderStatusPending, gameIDs).
+		Pluck("order_items.game_id", &ids)
+
+	pending := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		pending[id] = true
+	}
+	return pending
+}
+
+// lockOrderOwner locks the user the order's games go to, the buyer or the
+// gift recipient, so checkouts for the same owner run one at a time
+func lockOrderOwner(tx *gorm.DB, order models.Order) error {
+	ownerID := order.UserID
+	if order.GiftRecipientID != nil {
+		ownerID = *order.GiftRecipientID
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, ownerID).Error
+}
+
+// checkOrderGames makes sure none of the order's games is owned or on its
+// way to the owner yet. Call it after lockOrderOwner.
+func checkOrderGames(tx *gorm.DB, order models.Order) error {
+	gameIDs := make([]uint, len(order.Items))
+	for i, item := range order.Items {
+		gameIDs[i] = item.GameID
+	}
+
+	if order.GiftRecipientID != nil {
+		recipientID := *order.GiftRecipientID
+		if len(ownedGameIDs(tx, recipientID, gameIDs)) > 0 {
+			return purchaseConflict{utils.MsgRecipientOwnsGame}
+		}
+		if len(pendingGiftGameIDs(tx, recipientID, gameIDs)) > 0 {
+			return purchaseConflict{utils.MsgGiftPending}
+		}
+		return nil
+	}
+
+	if len(ownedGameIDs(tx, order.UserID, gameIDs)) > 0 {
+		return purchaseConflict{utils.MsgGameAlreadyOwned}
+	}
+	if len(pendingOrderGameIDs(tx, order.UserID, gameIDs)) > 0 {
+		return purchaseConflict{utils.MsgPaymentPending}
+	}
+	return nil
+}
+
+// bindCheckoutInput reads the optional checkout body; an empty body
+// selects the default payment method
+func bindCheckoutInput(c *gin.Context) (models.CheckoutInput, bool) {
+	var input models.CheckoutInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.BadRequest(c, err)
+		return input, false
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return input, false
+	}
+	return input, true
+}
+
+// paymentProvider resolves the payment method chosen by the buyer
+func paymentProvider(c *gin.Context, method string) (payments.Provider, bool) {
+	if method == "" {
+		return payments.Default(), true
+	}
+	provider, ok := payments.Get(method)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgPaymentProviderNotFound)
+	}
+	return provider, ok
+}
+
+// checkout creates a pending order with the given items (and gift
+// details) and charges it through the provider.
+// The owned and pending checks are repeated under a lock on the owner, so
+// concurrent checkouts can't both order the same game.
+// prepare, if given, runs in the transaction that creates the order.
+// Orders the provider settles right away are fulfilled before returning.
+func checkout(user models.User, provider payments.Provider, order models.Order, prepare func(tx *gorm.DB) error) (models.Order, payments.Payment, error) {
+	order.UserID = user.ID
+	order.Status = models.OrderStatusPending
+	order.Provider = provider.Name()
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrderOwner(tx, order); err != nil {
+			return err
+		}
+		if err := checkOrderGames(tx, order); err != nil {
+			return err
+		}
+
+		if prepare != nil {
+			if err := prepare(tx); err != nil {
+				return err
+			}
+		}
+
+		var coupon models.Coupon
+		if order.CouponCode != "" {
+			var err error
+			if coupon, err = applyCoupon(tx, user.ID, &order); err != nil {
+				return err
+			}
+		}
+
+		if err := applyTax(tx, user, &order); err != nil {
+			return err
+		}
+
+		var total float64
+		for _, item := range order.Items {
+			total += order.Charged(item)
+		}
+		order.Total = math.Round(total*100) / 100
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		if coupon.ID != 0 {
+			return redeemCoupon(tx, coupon, order)
+		}
+		return nil
+	})
+	if err != nil {
+		order.ID = 0
+		return order, payments.Payment{}, err
+	}
+
+	// Nothing to charge for free orders
+	if order.Total == 0 {
+		order, err := settleOrder(order.ID, models.OrderStatusPaid)
+		return order, payments.Payment{Status: payments.StatusPaid}, err
+	}
+
+	payment, err := provider.Charge(payments.Charge{
+		OrderID: order.ID,
+		UserID:  user.ID,
+		Amount:  order.Total,
+	})
+	if err != nil {
+		utils.LogError("Payment charge failed", map[string]interface{}{
+			"order_id": order.ID,
+			"error":    err.Error(),
+		})
+		order, _ = settleOrder(order.ID, models.OrderStatusFailed)
+		return order, payment, fmt.Errorf("%w: %w", errChargeFailed, err)
+	}
+
+	order.PaymentRef = payment.Reference
+	if err := db.DB.Model(&order).Update("payment_ref", payment.Reference).Error; err != nil {
+		return order, payment, err
+	}
+
+	if payment.Status != payments.StatusPending {
+		order, err = settleOrder(order.ID, payment.Status)
+	}
+	return order, payment, err
+}
+
+// settleOrder moves a pending order to paid or failed; paying grants
+// ownership of its games, or sends them as gifts, and issues the receipt.
+// Orders that already left pending are returned unchanged, so repeated
+// webhooks are harmless.
+func settleOrder(orderID uint, status string) (models.Order, error) {
+	var order models.Order
+	settled := false
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").Preload("Receipt").First(&order, orderID).Error; err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusPending {
+			return nil
+		}
+
+		switch status {
+		case payments.StatusPaid:
+			now := time.Now()
+			order.Status = models.OrderStatusPaid
+			order.PaidAt = &now
+			if order.GiftRecipientID != nil {
+				if err := sendGifts(tx, order); err != nil {
+					return err
+				}
+				break
+			}
+			for _, item := range order.Items {
+				if err := grantOwnership(tx, order.UserID, item.GameID, models.OwnershipSourcePurchase, nil); err != nil {
+					return err
+				}
+			}
+		case payments.StatusFailed:
+			order.Status = models.OrderStatusFailed
+			if err := releaseCoupon(tx, order); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+		if err := tx.Omit(clause.Associations).Save(&order).Error; err != nil {
+			return err
+		}
+		settled = true
+
+		if order.Status != models.OrderStatusPaid {
+			return nil
+		}
+		if err := recordSales(tx, order); err != nil {
+			return err
+		}
+		receipt, err := issueReceipt(tx, order)
+		if err != nil {
+			return err
+		}
+		order.Receipt = &receipt
+		return nil
+	})
+	if err != nil {
+		return order, err
+	}
+
+	if settled && order.Receipt != nil {
+		go func(receipt models.Receipt) {
+			if err := emailReceipt(receipt); err != nil {
+				utils.LogError("Failed to email receipt", map[string]interface{}{
+					"receipt": receipt.Number,
+					"error":   err.Error(),
+				})
+			}
+		}(*order.Receipt)
+	}
+
+	if order.Status == models.OrderStatusPaid && cache.IsRedisAvailable() {
+		cache.InvalidateUserLibrary(order.UserID)
+		cache.InvalidateDashboardStats()
+		utils.Log.Info(fmt.Sprintf("Library cache invalidated for user %d after order %d", order.UserID, order.ID))
+	}
+	return order, nil
+}
+
+// grantOwnership marks the game owned by the user, upgrading a wishlist
+// entry or a refunded ownership. source records how the game was acquired.
+// A game the user already owns is left as it is.
+func grantOwnership(tx *gorm.DB, userID, gameID uint, source string, giftID *uint) error {
+	now := time.Now()
+	ownership := models.Ownership{
+		UserID:     userID,
+		GameID:     gameID,
+		Status:     "owned",
+		AcquiredAt: &now,
+		Source:     source,
+		GiftID:     giftID,
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "game_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":      "owned",
+			"acquired_at": now,
+			"source":      source,
+			"gift_id":     giftID,
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Neq{Column: clause.Column{Table: "ownerships", Name: "status"}, Value: "owned"},
+		}},
+	}).Create(&ownership).Error
+}
+
+// refundOrderItem marks the order's item for the game refunded and the
+// whole order once nothing in it is left, and claws back the payee's
+// earnings. Returns the order and the amount to pay back, tax included.
+func refundOrderItem(tx *gorm.DB, orderID, gameID uint) (models.Order, float64, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, orderID).Error; err != nil {
+		return order, 0, err
+	}
+
+	now := time.Now()
+	var amount float64
+	allRefunded := true
+	for _, item := range order.Items {
+		if item.GameID == gameID && item.RefundedAt == nil {
+			if err := tx.Model(&item).Update("refunded_at", now).Error; err != nil {
+				return order, 0, err
+			}
+			if err := clawbackSale(tx, item.ID); err != nil {
+				return order, 0, err
+			}
+			amount = order.Charged(item)
+			continue
+		}
+		if item.RefundedAt == nil {
+			allRefunded = false
+		}
+	}
+	if allRefunded {
+		if err := tx.Model(&order).Update("status", models.OrderStatusRefunded).Error; err != nil {
+			return order, 0, err
+		}
+	}
+	return order, amount, nil
+}
+
+// txRefunder is a provider that refunds by writing to the database, like
+// the wallet. It does so in the refund's transaction.
+type txRefunder interface {
+	RefundTx(tx *gorm.DB, reference string, amount float64) error
+}
+
+// refundPayment pays the amount back through the provider the order was
+// paid with. Call it last in tx; providers that keep their books in the
+// database write in tx, so a rollback can't leave the money paid out.
+func refundPayment(tx *gorm.DB, order models.Order, amount float64) error {
+	if amount == 0 {
+		return nil
+	}
+	provider, ok := payments.Get(order.Provider)
+	if !ok {
+		return fmt.Errorf("payment provider %q is not registered", order.Provider)
+	}
+	if refunder, ok := provider.(txRefunder); ok {
+		return refunder.RefundTx(tx, order.PaymentRef, amount)
+	}
+	return provider.Refund(order.PaymentRef, amount)
+}
+
+// checkoutResponse sends the result of a checkout
+func checkoutResponse(c *gin.Context, order models.Order, payment payments.Payment, err error, body gin.H) {
+	var rejected couponError
+	if errors.As(err, &rejected) {
+		utils.ErrorResponse(c, http.StatusBadRequest, rejected.code)
+		return
+	}
+	var conflict purchaseConflict
+	if errors.As(err, &conflict) {
+		utils.ErrorResponse(c, http.StatusConflict, conflict.code)
+		return
+	}
+	if err != nil && order.ID == 0 {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgPurchaseFailed)
+		return
+	}
+	if errors.Is(err, errInsufficientFunds) {
+		c.JSON(http.StatusPaymentRequired, gin.H{
+			"error": utils.Message(c, utils.MsgInsufficientFunds),
+			"code":  utils.MsgInsufficientFunds,
+			"order": order,
+		})
+		return
+	}
+	if errors.Is(err, errChargeFailed) || order.Status == models.OrderStatusFailed {
+		c.JSON(http.StatusPaymentRequired, gin.H{
+			"error": utils.Message(c, utils.MsgPaymentFailed),
+			"code":  utils.MsgPaymentFailed,
+			"order": order,
+		})
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgPurchaseFailed)
+		return
+	}
+
+	body["order"] = order
+	if order.Status == models.OrderStatusPending {
+		body["message"] = "Payment pending"
+		body["checkout_url"] = payment.CheckoutURL
+		c.JSON(http.StatusAccepted, body)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// GetOrders - the current user's orders, newest first
+func GetOrders(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var orders []models.Order
+	if err := db.DB.Where("user_id = ?", user.ID).Preload("Items").Preload("Receipt").Order("id DESC").Find(&orders).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchOrdersFailed)
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetOrderByID - an order of the current user, or any order for admins
+func GetOrderByID(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var order models.Order
+	if err := db.DB.Preload("Items").Preload("Receipt").First(&order, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOrderNotFound)
+		return
+	}
+	if order.UserID != user.ID && user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOrderNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// PaymentWebhook - asynchronous payment confirmation from a provider.
+// The payload must be signed, see payments.SignatureHeader.
+// POST /payments/webhook/:provider
+func PaymentWebhook(c *gin.Context) {
+	provider, ok := payments.Get(c.Param("provider"))
+	if !ok {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgPaymentProviderNotFound)
+		return
+	}
+
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+
+	event, err := provider.ParseWebhook(payload, c.GetHeader(payments.SignatureHeader))
+	if errors.Is(err, payments.ErrInvalidSignature) {
+		utils.ErrorResponse(c, http.StatusUnauthorized, utils.MsgInvalidWebhookSignature)
+		return
+	}
+	if err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+
+	var order models.Order
+	if err := db.DB.Where("provider = ? AND payment_ref = ?", provider.Name(), event.Reference).First(&order).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOrderNotFound)
+		return
+	}
+
+	order, err = settleOrder(order.ID, event.Status)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgInternalError)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Payment webhook: order %d is %s", order.ID, order.Status))
+	c.JSON(http.StatusOK, gin.H{"order_id": order.ID, "status": order.Status})
+}
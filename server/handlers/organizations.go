@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+var errLastOwner = errors.New("organization must keep at least one owner")
+
+// orgRole returns the user's role in the organization, or "" if not a member
+func orgRole(orgID, userID uint) string {
+	var member models.OrganizationMember
+	if err := db.DB.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&member).Error; err != nil {
+		return ""
+	}
+	return member.Role
+}
+
+// hasOrgRole reports whether the user has one of the given roles in the organization.
+// Admins pass every check.
+func hasOrgRole(user models.User, orgID uint, roles ...string) bool {
+	if user.Role == "admin" {
+		return true
+	}
+	role := orgRole(orgID, user.ID)
+	for _, r := range roles {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// canManageGame reports whether the user may edit or delete the game.
+// Games of an organization are managed by its owners and editors;
+// games without one by their developer.
+func canManageGame(user models.User, game models.Game) bool {
+	if user.Role == "admin" {
+		return true
+	}
+	if game.OrganizationID != nil {
+		return hasOrgRole(user, *game.OrganizationID, models.OrgRoleOwner, models.OrgRoleEditor)
+	}
+	return user.Role == "developer" && user.ID == game.DeveloperID
+}
+
+// GetOrganizations - public list of studios
+func GetOrganizations(c *gin.Context) {
+	var orgs []models.Organization
+	if err := db.DB.Order("name").Find(&orgs).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchOrganizationsFail)
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+// GetOrganizationByID - public studio page with its games
+func GetOrganizationByID(c *gin.Context) {
+	var org models.Organization
+	if err := db.DB.First(&org, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOrganizationNotFound)
+		return
+	}
+
+	var games []models.Game
+	if err := db.DB.Where("organization_id = ?", org.ID).Preload("Category").Find(&games).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchGamesFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"organization": org,
+		"games":        games,
+		"total_games":  len(games),
+	})
+}
+
+// CreateOrganization - the creator becomes its owner
+func CreateOrganization(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" && user.Role != "developer" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOrDevelopers)
+		return
+	}
+
+	var input models.OrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	org := models.Organization{
+		Name:        input.Name,
+		Description: input.Description,
+		Website:     input.Website,
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{
+			OrganizationID: org.ID,
+			UserID:         user.ID,
+			Role:           models.OrgRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCreateOrganizationFail)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// UpdateOrganization - owners only
+func UpdateOrganization(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var org models.Organization
+	if err := db.DB.First(&org, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOrganizationNotFound)
+		return
+	}
+	if !hasOrgRole(user, org.ID, models.OrgRoleOwner) {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgOrgOwnersOnly)
+		return
+	}
+
+	var input models.OrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	org.Name = input.Name
+	org.Description = input.Description
+	org.Website = input.Website
+	if err := db.DB.Save(&org).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateOrganizationFail)
+		return
+	}
+
+	// Games include organization info
+	if cache.IsRedisAvailable() {
+		cache.InvalidateGamesList()
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// GetOrganizationMembers - visible to members of the organization
+func GetOrganizationMembers(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidOrganizationID)
+		return
+	}
+	if !hasOrgRole(user, uint(orgID), models.OrgRoleOwner, models.OrgRoleEditor, models.OrgRoleAnalyst) {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgOrgMembersOnly)
+		return
+	}
+
+	var members []models.OrganizationMember
+	if err := db.DB.Where("organization_id = ?", orgID).Preload("User").Find(&members).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchOrganizationsFail)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// AddOrganizationMember - owners add a user with a role, or change an existing member's role
+// POST /organizations/:id/members
+func AddOrganizationMember(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var org models.Organization
+	if err := db.DB.First(&org, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOrganizationNotFound)
+		return
+	}
+	if !hasOrgRole(user, org.ID, models.OrgRoleOwner) {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgOrgOwnersOnly)
+		return
+	}
+
+	var input models.OrganizationMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var target models.User
+	if err := db.DB.First(&target, input.UserID).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgUserNotFound)
+		return
+	}
+
+	var member models.OrganizationMember
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("organization_id = ? AND user_id = ?", org.ID, target.ID).First(&member).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			member = models.OrganizationMember{
+				OrganizationID: org.ID,
+				UserID:         target.ID,
+				Role:           input.Role,
+			}
+			return tx.Create(&member).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if member.Role == models.OrgRoleOwner && input.Role != models.OrgRoleOwner {
+			if err := ensureAnotherOwner(tx, org.ID, target.ID); err != nil {
+				return err
+			}
+		}
+		member.Role = input.Role
+		return tx.Save(&member).Error
+	})
+	if errors.Is(err, errLastOwner) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgOrgLastOwner)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateOrganizationFail)
+		return
+	}
+
+	member.User = target
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveOrganizationMember - owners remove anyone, members can leave
+// DELETE /organizations/:id/members/:userId
+func RemoveOrganizationMember(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidOrganizationID)
+		return
+	}
+	memberUserID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidUserID)
+		return
+	}
+
+	if user.ID != uint(memberUserID) && !hasOrgRole(user, uint(orgID), models.OrgRoleOwner) {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgOrgOwnersOnly)
+		return
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var member models.OrganizationMember
+		if err := tx.Where("organization_id = ? AND user_id = ?", orgID, memberUserID).First(&member).Error; err != nil {
+			return err
+		}
+		if member.Role == models.OrgRoleOwner {
+			if err := ensureAnotherOwner(tx, member.OrganizationID, member.UserID); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&member).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOrgMemberNotFound)
+		return
+	}
+	if errors.Is(err, errLastOwner) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgOrgLastOwner)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateOrganizationFail)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("User %d removed from organization %d", memberUserID, orgID))
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// ensureAnotherOwner fails if userID is the organization's only owner
+func ensureAnotherOwner(tx *gorm.DB, orgID, userID uint) error {
+	var owners int64
+	if err := tx.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ? AND user_id <> ?", orgID, models.OrgRoleOwner, userID).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners == 0 {
+		return errLastOwner
+	}
+	return nil
+}
@@ -7,46 +7,82 @@ import (
 	"awesomeProject/utils"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"net/http"
 )
 
-// BuyGame with cache invalidation
+// BuyGame places an order for the game and charges it.
+// Ownership is granted once the order is paid, right away or via the payment webhook.
+// With a recipient the game is bought as a gift and lands in their inbox instead.
 func BuyGame(c *gin.Context) {
-	var ownership models.Ownership
-	if err := c.ShouldBindJSON(&ownership); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var input models.BuyGameInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
 	user := c.MustGet("user").(models.User)
-	ownership.UserID = user.ID
-	ownership.Status = "owned"
 
 	var game models.Game
-	if err := db.DB.First(&game, ownership.GameID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+	if err := db.DB.First(&game, input.GameID).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidGameID)
 		return
 	}
 
-	var existing models.Ownership
-	if err := db.DB.Where("user_id = ? AND game_id = ?", user.ID, ownership.GameID).First(&existing).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Game already owned"})
+	// A gift goes to the recipient, so the checks apply to them
+	owner := user
+	if input.IsGift() {
+		recipient, ok := resolveGiftRecipient(c, user, input)
+		if !ok {
+			return
+		}
+		owner = recipient
+	}
+
+	if game.IsAddOn() {
+		owned := ownedGameIDs(db.DB, owner.ID, []uint{*game.BaseGameID})
+		if !owned[*game.BaseGameID] {
+			utils.ErrorResponse(c, http.StatusForbidden, utils.MsgBaseGameNotOwned)
+			return
+		}
+	}
+
+	if ownedGameIDs(db.DB, owner.ID, []uint{game.ID})[game.ID] {
+		if input.IsGift() {
+			utils.ErrorResponse(c, http.StatusConflict, utils.MsgRecipientOwnsGame)
+		} else {
+			utils.ErrorResponse(c, http.StatusConflict, utils.MsgGameAlreadyOwned)
+		}
+		return
+	}
+	if input.IsGift() && pendingGiftGameIDs(db.DB, owner.ID, []uint{game.ID})[game.ID] {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGiftPending)
+		return
+	}
+	if !input.IsGift() && pendingOrderGameIDs(db.DB, user.ID, []uint{game.ID})[game.ID] {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgPaymentPending)
 		return
 	}
 
-	if err := db.DB.Create(&ownership).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purchase game"})
+	provider, ok := paymentProvider(c, input.PaymentMethod)
+	if !ok {
 		return
 	}
 
-	// Invalidate user's library cache and stats
-	if cache.IsRedisAvailable() {
-		cache.InvalidateUserLibrary(user.ID)
-		cache.InvalidateDashboardStats()
-		utils.Log.Info(fmt.Sprintf("Library cache invalidated for user %d after purchase", user.ID))
+	order := models.Order{Items: orderItemsFor([]models.Game{game}), CouponCode: input.CouponCode}
+	message := "Game purchased"
+	if input.IsGift() {
+		order.GiftRecipientID = &owner.ID
+		order.GiftMessage = input.GiftMessage
+		message = "Gift sent"
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Game purchased"})
+	order, payment, err := checkout(user, provider, order, nil)
+	checkoutResponse(c, order, payment, err, gin.H{"message": message})
 }
 
 // GetLibrary with Redis caching
@@ -54,32 +90,90 @@ func GetLibrary(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 
 	// Try cache first
+	var games []libraryEntry
+	cached := false
 	if cache.IsRedisAvailable() {
-		cachedLibrary, err := cache.GetUserLibrary(user.ID)
-		if err == nil && cachedLibrary != nil {
+		if err := cache.GetUserLibrary(user.ID, &games); err == nil && games != nil {
 			utils.Log.Debug(fmt.Sprintf("Cache HIT: library for user %d", user.ID))
-			c.JSON(http.StatusOK, cachedLibrary)
-			return
+			cached = true
+		} else {
+			utils.Log.Debug(fmt.Sprintf("Cache MISS: library for user %d", user.ID))
 		}
-		utils.Log.Debug(fmt.Sprintf("Cache MISS: library for user %d", user.ID))
 	}
 
 	// Fetch from database
-	var ownerships []models.Ownership
-	if err := db.DB.Where("user_id = ?", user.ID).Preload("Game").Find(&ownerships).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch library"})
+	if !cached {
+		var ownerships []models.Ownership
+		if err := db.DB.Where("user_id = ? AND status = ?", user.ID, "owned").Preload("Game").Find(&ownerships).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchLibraryFailed)
+			return
+		}
+
+		games = groupLibrary(ownerships)
+
+		// Cache the result
+		if cache.IsRedisAvailable() {
+			cache.SetUserLibrary(user.ID, games)
+		}
+	}
+
+	// Games lent by the family aren't cached, lending can stop any time
+	shared, err := sharedLibrary(user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchLibraryFailed)
 		return
 	}
 
-	var games []models.Game
+	c.JSON(http.StatusOK, append(games, shared...))
+}
+
+// libraryEntry - an owned game with its owned add-ons grouped under it.
+// SharedBy is set on games lent by a family member.
+type libraryEntry struct {
+	models.Game
+	AddOns   []models.Game `json:"addOns,omitempty"`
+	SharedBy *familyLender `json:"sharedBy,omitempty"`
+}
+
+// groupLibrary nests owned add-ons under their base games.
+// Add-ons whose base game isn't in the library stay at the top level.
+func groupLibrary(ownerships []models.Ownership) []libraryEntry {
+	entries := make([]libraryEntry, 0, len(ownerships))
+	baseIndex := make(map[uint]int)
+
 	for _, o := range ownerships {
-		games = append(games, o.Game)
+		if !o.Game.IsAddOn() {
+			baseIndex[o.Game.ID] = len(entries)
+			entries = append(entries, libraryEntry{Game: o.Game})
+		}
+	}
+	for _, o := range ownerships {
+		if !o.Game.IsAddOn() {
+			continue
+		}
+		if i, ok := baseIndex[*o.Game.BaseGameID]; ok {
+			entries[i].AddOns = append(entries[i].AddOns, o.Game)
+		} else {
+			entries = append(entries, libraryEntry{Game: o.Game})
+		}
 	}
 
-	// Cache the result
-	if cache.IsRedisAvailable() {
-		cache.SetUserLibrary(user.ID, games)
+	return entries
+}
+
+// ownedGameIDs returns which of the given games the user owns
+func ownedGameIDs(tx *gorm.DB, userID uint, gameIDs []uint) map[uint]bool {
+	owned := make(map[uint]bool)
+	if len(gameIDs) == 0 {
+		return owned
 	}
 
-	c.JSON(http.StatusOK, games)
+	var ids []uint
+	tx.Model(&models.Ownership{}).
+		Where("user_id = ? AND game_id IN ? AND status = ?", userID, gameIDs, "owned").
+		Pluck("game_id", &ids)
+	for _, id := range ids {
+		owned[id] = true
+	}
+	return owned
 }
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/mail"
+	"awesomeProject/models"
+	"awesomeProject/receipts"
+	"awesomeProject/utils"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// issueReceipt numbers and stores the receipt of a paid order
+func issueReceipt(tx *gorm.DB, order models.Order) (models.Receipt, error) {
+	var buyer models.User
+	if err := tx.Select("id", "name", "email").First(&buyer, order.UserID).Error; err != nil {
+		return models.Receipt{}, err
+	}
+
+	var seq int64
+	if err := tx.Raw("SELECT nextval('receipt_number_seq')").Scan(&seq).Error; err != nil {
+		return models.Receipt{}, err
+	}
+
+	now := time.Now()
+	receipt := models.Receipt{
+		Number:        fmt.Sprintf("R-%d-%06d", now.Year(), seq),
+		OrderID:       order.ID,
+		UserID:        order.UserID,
+		BillingName:   buyer.Name,
+		BillingEmail:  buyer.Email,
+		Lines:         make(models.ReceiptLines, len(order.Items)),
+		Tax:           order.Tax,
+		TaxName:       order.TaxName,
+		TaxRate:       order.TaxRate,
+		TaxInclusive:  order.TaxInclusive,
+		Total:         order.Total,
+		CouponCode:    order.CouponCode,
+		PaymentMethod: order.Provider,
+		PaymentRef:    order.PaymentRef,
+		IssuedAt:      now,
+	}
+	for i, item := range order.Items {
+		receipt.Lines[i] = models.ReceiptLine{
+			GameID:    item.GameID,
+			Name:      item.Name,
+			ListPrice: item.ListPrice,
+			Discount:  math.Round((item.ListPrice-item.Price)*100) / 100,
+			Price:     item.Price,
+			Tax:       item.Tax,
+		}
+		receipt.Subtotal += item.ListPrice
+		receipt.Discount += receipt.Lines[i].Discount
+	}
+	receipt.Subtotal = math.Round(receipt.Subtotal*100) / 100
+	receipt.Discount = math.Round(receipt.Discount*100) / 100
+
+	return receipt, tx.Create(&receipt).Error
+}
+
+// emailReceipt sends the receipt to the buyer with the PDF attached
+func emailReceipt(receipt models.Receipt) error {
+	html, err := receipts.HTML(receipt)
+	if err != nil {
+		return err
+	}
+	pdf, err := receipts.PDF(receipt)
+	if err != nil {
+		return err
+	}
+
+	return mail.Default().Send(mail.Message{
+		To:      receipt.BillingEmail,
+		Subject: "Your receipt " + receipt.Number,
+		HTML:    string(html),
+		Attachments: []mail.Attachment{{
+			Filename:    receipt.Number + ".pdf",
+			ContentType: "application/pdf",
+			Data:        pdf,
+		}},
+	})
+}
+
+// purchaseEntry - a receipt in the purchase history with the order's status
+type purchaseEntry struct {
+	models.Receipt
+	OrderStatus string `json:"orderStatus"`
+}
+
+// GetPurchaseHistory - the current user's receipts, newest first
+// GET /purchases
+func GetPurchaseHistory(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var entries []purchaseEntry
+	err := db.DB.Model(&models.Receipt{}).
+		Select("receipts.*, orders.status AS order_status").
+		Joins("JOIN orders ON orders.id = receipts.order_id").
+		Where("receipts.user_id = ?", user.ID).
+		Order("receipts.issued_at DESC").
+		Scan(&entries).Error
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchReceiptsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// loadReceipt loads a receipt of the current user, or any receipt for admins
+func loadReceipt(c *gin.Context, user models.User) (models.Receipt, bool) {
+	var receipt models.Receipt
+	if err := db.DB.First(&receipt, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgReceiptNotFound)
+		return receipt, false
+	}
+	if receipt.UserID != user.ID && user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgReceiptNotFound)
+		return receipt, false
+	}
+	return receipt, true
+}
+
+// GetReceipt - a receipt as JSON, or a document to download
+// GET /receipts/:id?format=json|html|pdf
+func GetReceipt(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	receipt, ok := loadReceipt(c, user)
+	if !ok {
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	var data []byte
+	var err error
+	var contentType string
+	switch format {
+	case "json":
+		c.JSON(http.StatusOK, receipt)
+		return
+	case "html":
+		data, err = receipts.HTML(receipt)
+		contentType = "text/html; charset=utf-8"
+	case "pdf":
+		data, err = receipts.PDF(receipt)
+		contentType = "application/pdf"
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidReceiptFormat)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgRenderReceiptFailed)
+		return
+	}
+
+	filename := fmt.Sprintf("%s.%s", receipt.Number, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// EmailReceipt - send a receipt to the buyer's email again
+// POST /receipts/:id/email
+func EmailReceipt(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	receipt, ok := loadReceipt(c, user)
+	if !ok {
+		return
+	}
+
+	if err := emailReceipt(receipt); err != nil {
+		utils.LogError("Failed to send receipt", map[string]interface{}{
+			"receipt": receipt.Number,
+			"error":   err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSendReceiptFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Receipt sent to " + receipt.BillingEmail})
+}
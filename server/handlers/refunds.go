@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/refunds"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errRefundNotPending       = errors.New("refund request already decided")
+	errRefundAlreadyRequested = errors.New("refund already requested")
+	errNotRefundable          = errors.New("game is not owned")
+	errGiftNotRefundable      = errors.New("gifted games can't be refunded")
+)
+
+// requestRefund records a refund request for an owned game and evaluates
+// it against the refund policy. The ownership row is locked so concurrent
+// requests for the same game can't both go through.
+func requestRefund(user models.User, gameID uint, reason string) (models.RefundRequest, refunds.Decision, error) {
+	var request models.RefundRequest
+	var decision refunds.Decision
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var ownership models.Ownership
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND game_id = ?", user.ID, gameID).First(&ownership).Error; err != nil {
+			return err
+		}
+		if ownership.Status != "owned" {
+			return errNotRefundable
+		}
+		if ownership.Source == models.OwnershipSourceGift {
+			return errGiftNotRefundable
+		}
+
+		var pending int64
+		if err := tx.Model(&models.RefundRequest{}).
+			Where("user_id = ? AND game_id = ? AND status = ?", user.ID, gameID, models.RefundStatusPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return errRefundAlreadyRequested
+		}
+
+		request = models.RefundRequest{
+			UserID:          user.ID,
+			GameID:          gameID,
+			Status:          models.RefundStatusPending,
+			Reason:          reason,
+			PlaytimeMinutes: ownership.PlaytimeMinutes,
+			PurchasedAt:     ownership.AcquiredAt,
+		}
+
+		// The latest paid order with this game that wasn't refunded yet
+		var item models.OrderItem
+		err := tx.Joins("JOIN orders ON orders.id = order_items.order_id").
+			Where("orders.user_id = ? AND orders.status = ? AND orders.gift_recipient_id IS NULL AND order_items.game_id = ? AND order_items.refunded_at IS NULL",
+				user.ID, models.OrderStatusPaid, gameID).
+			Order("orders.paid_at DESC").
+			First(&item).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			var order models.Order
+			if err := tx.First(&order, item.OrderID).Error; err != nil {
+				return err
+			}
+			request.OrderID = &order.ID
+			request.Amount = order.Charged(item)
+			if order.PaidAt != nil {
+				request.PurchasedAt = order.PaidAt
+			}
+		}
+
+		policy := refunds.Current()
+		now := time.Now()
+		var recent int64
+		if err := tx.Model(&models.RefundRequest{}).
+			Where("user_id = ? AND status = ? AND decided_at > ?", user.ID, models.RefundStatusApproved, now.Add(-policy.CountPeriod)).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+
+		facts := refunds.Request{PlaytimeMinutes: ownership.PlaytimeMinutes, RecentRefunds: recent}
+		if request.PurchasedAt != nil {
+			facts.PurchasedAt = *request.PurchasedAt
+		}
+		decision = policy.Evaluate(facts, now)
+		request.PolicyViolations = strings.Join(decision.Reasons, ",")
+
+		return tx.Create(&request).Error
+	})
+	return request, decision, err
+}
+
+// approveRefund marks the game refunded, keeping the ownership row, and
+// returns the money through the provider the order was paid with. The
+// provider is called last so a failed refund rolls everything back.
+func approveRefund(requestID uint, reviewer *models.User, note string) (models.RefundRequest, error) {
+	var request models.RefundRequest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, requestID).Error; err != nil {
+			return err
+		}
+		if request.Status != models.RefundStatusPending {
+			return errRefundNotPending
+		}
+
+		var ownership models.Ownership
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND game_id = ?", request.UserID, request.GameID).First(&ownership).Error; err != nil {
+			return err
+		}
+		if ownership.Status != "owned" {
+			return errNotRefundable
+		}
+		if err := tx.Model(&ownership).Update("status", "refunded").Error; err != nil {
+			return err
+		}
+
+		var order models.Order
+		if request.OrderID != nil {
+			var err error
+			// Pays back what the item was charged, exclusive tax included
+			if order, request.Amount, err = refundOrderItem(tx, *request.OrderID, request.GameID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		request.Status = models.RefundStatusApproved
+		request.DecidedAt = &now
+		request.ReviewNote = note
+		if reviewer != nil {
+			request.ReviewedByID = &reviewer.ID
+		}
+		if err := tx.Omit("Game").Save(&request).Error; err != nil {
+			return err
+		}
+
+		if order.ID == 0 {
+			return nil
+		}
+		return refundPayment(tx, order, request.Amount)
+	})
+	if err != nil {
+		return request, err
+	}
+
+	// A refunded copy can't be lent any more
+	dropCopyLeases(request.UserID, request.GameID)
+
+	if cache.IsRedisAvailable() {
+		cache.InvalidateUserLibrary(request.UserID)
+		cache.InvalidateDashboardStats()
+		utils.Log.Info(fmt.Sprintf("Library cache invalidated for user %d after refund %d", request.UserID, request.ID))
+	}
+	return request, nil
+}
+
+// refundErrorResponse maps refund errors to responses
+func refundErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgRefundNotFound)
+	case errors.Is(err, errRefundNotPending):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgRefundNotPending)
+	case errors.Is(err, errNotRefundable):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGameNotRefundable)
+	case errors.Is(err, errGiftNotRefundable):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGiftNotRefundable)
+	default:
+		utils.LogError("Refund failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgRefundFailed)
+	}
+}
+
+// GetRefunds - the current user's refund requests, newest first
+// GET /refunds
+func GetRefunds(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var requests []models.RefundRequest
+	if err := db.DB.Where("user_id = ?", user.ID).Preload("Game").Order("id DESC").Find(&requests).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchRefundsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// GetRefundQueue - refund requests for review, oldest first
+// GET /admin/refunds?status=pending
+func GetRefundQueue(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	status := c.DefaultQuery("status", models.RefundStatusPending)
+
+	var requests []models.RefundRequest
+	if err := db.DB.Where("status = ?", status).Preload("Game").Order("id ASC").Find(&requests).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchRefundsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// bindRefundReview reads the optional admin note
+func bindRefundReview(c *gin.Context) (models.RefundReviewInput, bool) {
+	var input models.RefundReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.BadRequest(c, err)
+		return input, false
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return input, false
+	}
+	return input, true
+}
+
+// ApproveRefund - approve a queued refund request
+// POST /admin/refunds/:id/approve
+func ApproveRefund(c *gin.Context) {
+	admin := c.MustGet("user").(models.User)
+	if admin.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	input, ok := bindRefundReview(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgRefundNotFound)
+		return
+	}
+
+	request, err := approveRefund(uint(id), &admin, input.Note)
+	if err != nil {
+		refundErrorResponse(c, err)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Refund %d approved by admin %d", request.ID, admin.ID))
+	c.JSON(http.StatusOK, request)
+}
+
+// RejectRefund - reject a queued refund request, the user keeps the game
+// POST /admin/refunds/:id/reject
+func RejectRefund(c *gin.Context) {
+	admin := c.MustGet("user").(models.User)
+	if admin.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	input, ok := bindRefundReview(c)
+	if !ok {
+		return
+	}
+
+	var request models.RefundRequest
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, c.Param("id")).Error; err != nil {
+			return err
+		}
+		if request.Status != models.RefundStatusPending {
+			return errRefundNotPending
+		}
+
+		now := time.Now()
+		request.Status = models.RefundStatusRejected
+		request.DecidedAt = &now
+		request.ReviewedByID = &admin.ID
+		request.ReviewNote = input.Note
+		return tx.Omit("Game").Save(&request).Error
+	})
+	if err != nil {
+		refundErrorResponse(c, err)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Refund %d rejected by admin %d", request.ID, admin.ID))
+	c.JSON(http.StatusOK, request)
+}
@@ -28,14 +28,14 @@ func Register(c *gin.Context) {
 	// Проверка существующего email
 	var existingUser models.User
 	if err := db.DB.Where("email = ?", input.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgEmailExists)
 		return
 	}
 
 	// Хеширование пароля
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgHashPasswordFailed)
 		return
 	}
 
@@ -45,7 +45,7 @@ func Register(c *gin.Context) {
 	if err == nil {
 		avatarPath = "uploads/" + file.Filename
 		if err := c.SaveUploadedFile(file, avatarPath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveAvatarFailed)
 			return
 		}
 		log.Printf("Avatar saved to: %s", avatarPath)
@@ -61,7 +61,7 @@ func Register(c *gin.Context) {
 	}
 
 	if err := db.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCreateUserFailed)
 		return
 	}
 
@@ -7,24 +7,64 @@ import (
 	"awesomeProject/utils"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
 	"net/http"
 	"strconv"
 )
 
-// CreateReview with cache invalidation
-func CreateReview(c *gin.Context) {
-	var review models.Review
+// reviewPlaytime returns the playtime to show on a review, or nil when none
+// has been recorded for the game
+func reviewPlaytime(ownership models.Ownership) *int {
+	if ownership.PlaytimeMinutes <= 0 {
+		return nil
+	}
+	minutes := ownership.PlaytimeMinutes
+	return &minutes
+}
 
-	if err := c.ShouldBindJSON(&review); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// CreateReview - review an owned game, once per game
+// POST /reviews
+func CreateReview(c *gin.Context) {
+	var input models.ReviewCreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if errs := utils.ValidateStruct(input); errs != nil {
+		utils.ValidationErrorResponse(c, errs)
 		return
 	}
 
 	user := c.MustGet("user").(models.User)
-	review.UserID = user.ID
 
-	if err := db.DB.Create(&review).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create review"})
+	var game models.Game
+	if err := db.DB.Select("id").First(&game, input.GameID).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
+		return
+	}
+
+	var ownership models.Ownership
+	if err := db.DB.Where("user_id = ? AND game_id = ? AND status = ?", user.ID, game.ID, "owned").
+		First(&ownership).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgReviewRequiresOwnership)
+		return
+	}
+
+	review := models.Review{
+		UserID:          user.ID,
+		GameID:          game.ID,
+		Rating:          input.Rating,
+		Comment:         input.Comment,
+		PlaytimeMinutes: reviewPlaytime(ownership),
+	}
+	// The unique index settles two reviews posted at the same time
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&review)
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgCreateReviewFailed)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgReviewExists)
 		return
 	}
 
@@ -37,6 +77,69 @@ func CreateReview(c *gin.Context) {
 	c.JSON(http.StatusOK, review)
 }
 
+// UpdateReview - change the rating or comment of one's own review
+// PUT /reviews/:id
+func UpdateReview(c *gin.Context) {
+	var review models.Review
+	if err := db.DB.First(&review, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgReviewNotFound)
+		return
+	}
+
+	user := c.MustGet("user").(models.User)
+	if user.ID != review.UserID {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgReviewAuthorOnly)
+		return
+	}
+
+	var input models.ReviewUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if errs := utils.ValidateStruct(input); errs != nil {
+		utils.ValidationErrorResponse(c, errs)
+		return
+	}
+
+	changed := false
+	if input.Rating != nil && *input.Rating != review.Rating {
+		review.Rating = *input.Rating
+		changed = true
+	}
+	if input.Comment != nil && *input.Comment != review.Comment {
+		review.Comment = *input.Comment
+		changed = true
+	}
+	// Saving the same text again is no edit and keeps the playtime the
+	// review was written with
+	if !changed {
+		c.JSON(http.StatusOK, review)
+		return
+	}
+	review.Edited = true
+
+	// Refresh playtime so it matches what the author had played when the
+	// content changed
+	var ownership models.Ownership
+	if err := db.DB.Where("user_id = ? AND game_id = ? AND status = ?", user.ID, review.GameID, "owned").
+		First(&ownership).Error; err == nil {
+		review.PlaytimeMinutes = reviewPlaytime(ownership)
+	}
+
+	if err := db.DB.Select("Rating", "Comment", "Edited", "PlaytimeMinutes").Save(&review).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateReviewFailed)
+		return
+	}
+
+	if cache.IsRedisAvailable() {
+		cache.InvalidateReviews(review.GameID)
+		utils.Log.Info(fmt.Sprintf("Reviews cache invalidated for game %d after edit", review.GameID))
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
 // GetReviews with Redis caching
 func GetReviews(c *gin.Context) {
 	gameID := c.Query("gameId")
@@ -62,7 +165,7 @@ func GetReviews(c *gin.Context) {
 		query = query.Where("game_id = ?", gameID)
 	}
 	if err := query.Find(&reviews).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchReviewsFailed)
 		return
 	}
 
@@ -82,20 +185,20 @@ func DeleteReview(c *gin.Context) {
 	id := c.Param("id")
 	var review models.Review
 	if err := db.DB.First(&review, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgReviewNotFound)
 		return
 	}
 
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" && user.ID != review.UserID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins or review author can delete"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgReviewAuthorOrAdmins)
 		return
 	}
 
 	gameID := review.GameID // Save before deletion
 
 	if err := db.DB.Delete(&review).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgDeleteReviewFailed)
 		return
 	}
 
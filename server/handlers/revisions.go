@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+// recordGameRevision stores what changed on the game since before.
+// Nothing is stored when no tracked field changed.
+func recordGameRevision(tx *gorm.DB, editor models.User, action string, before models.GameSnapshot, game models.Game, rolledBackTo *uint) error {
+	after := models.SnapshotGame(game)
+	changes := models.DiffSnapshots(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	revision := models.GameRevision{
+		GameID:       game.ID,
+		EditorID:     editor.ID,
+		EditorName:   editor.Name,
+		Action:       action,
+		Changes:      changes,
+		Snapshot:     after,
+		RolledBackTo: rolledBackTo,
+	}
+	return tx.Create(&revision).Error
+}
+
+// loadManagedGame loads the game from the :id param if the user may manage it
+func loadManagedGame(c *gin.Context, user models.User) (models.Game, bool) {
+	var game models.Game
+	if err := db.DB.First(&game, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
+		return game, false
+	}
+	if !canManageGame(user, game) {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
+		return game, false
+	}
+	return game, true
+}
+
+// GetGameRevisions - change history of a game, newest first
+// GET /games/:id/revisions
+func GetGameRevisions(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	game, ok := loadManagedGame(c, user)
+	if !ok {
+		return
+	}
+
+	var revisions []models.GameRevision
+	if err := db.DB.Where("game_id = ?", game.ID).Order("id DESC").Find(&revisions).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchRevisionsFailed)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetGameRevision - one revision with a diff against the current state,
+// or against another revision with ?against=<revisionId>
+// GET /games/:id/revisions/:revisionId
+func GetGameRevision(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	game, ok := loadManagedGame(c, user)
+	if !ok {
+		return
+	}
+
+	var revision models.GameRevision
+	if err := db.DB.Where("game_id = ?", game.ID).First(&revision, c.Param("revisionId")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgRevisionNotFound)
+		return
+	}
+
+	base := models.SnapshotGame(game)
+	against := "current"
+	if againstID := c.Query("against"); againstID != "" {
+		var other models.GameRevision
+		if err := db.DB.Where("game_id = ?", game.ID).First(&other, againstID).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusNotFound, utils.MsgRevisionNotFound)
+			return
+		}
+		base = other.Snapshot
+		against = strconv.Itoa(int(other.ID))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revision": revision,
+		"against":  against,
+		"diff":     models.DiffSnapshots(base, revision.Snapshot),
+	})
+}
+
+// RollbackGame restores the game to the state saved in a revision.
+// The rollback itself is recorded as a new revision.
+// POST /games/:id/revisions/:revisionId/rollback
+func RollbackGame(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	game, ok := loadManagedGame(c, user)
+	if !ok {
+		return
+	}
+
+	var revision models.GameRevision
+	if err := db.DB.Where("game_id = ?", game.ID).First(&revision, c.Param("revisionId")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgRevisionNotFound)
+		return
+	}
+
+	before := models.SnapshotGame(game)
+	target := revision.Snapshot
+
+	// The old slug may belong to another game by now
+	if target.Slug != game.Slug {
+		slug, err := db.UniqueSlug(db.DB, "games", target.Slug, game.ID)
+		if err != nil || slug != target.Slug {
+			target.Slug = game.Slug
+		}
+	}
+
+	// Rows the revision refers to may be gone
+	if target.CategoryID != game.CategoryID {
+		var category models.Category
+		if err := db.DB.First(&category, target.CategoryID).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusConflict, utils.MsgCategoryNotFound)
+			return
+		}
+	}
+	if target.Type != game.Type || !sameID(target.BaseGameID, game.BaseGameID) {
+		baseID := ""
+		if target.BaseGameID != nil {
+			baseID = strconv.Itoa(int(*target.BaseGameID))
+		}
+		if _, code := resolveBaseGame(target.Type, baseID, game.ID); code != "" {
+			utils.ErrorResponse(c, http.StatusConflict, code)
+			return
+		}
+	}
+	if target.OrganizationID != nil && !sameID(target.OrganizationID, game.OrganizationID) {
+		if _, code := resolveGameOrganization(user, strconv.Itoa(int(*target.OrganizationID))); code != "" {
+			utils.ErrorResponse(c, http.StatusForbidden, code)
+			return
+		}
+	}
+
+	target.ApplyTo(&game)
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Platforms").Save(&game).Error; err != nil {
+			return err
+		}
+		if err := moveSlug(tx, models.SlugEntityGame, game.ID, before.Slug, game.Slug); err != nil {
+			return err
+		}
+		return recordGameRevision(tx, user, models.RevisionRollback, before, game, &revision.ID)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgRollbackFailed)
+		return
+	}
+
+	db.DB.Preload("Category").Preload("Platforms").First(&game, game.ID)
+	notifyPriceDrop(before, game)
+
+	// Invalidate caches
+	if cache.IsRedisAvailable() {
+		cache.InvalidateGame(game.ID)
+		cache.InvalidateGamesList()
+		cache.InvalidateSlug(models.SlugEntityGame, before.Slug)
+		utils.Log.Info(fmt.Sprintf("Game %d rolled back to revision %d", game.ID, revision.ID))
+	}
+
+	c.JSON(http.StatusOK, game)
+}
+
+// sameID reports whether two optional IDs are equal
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"awesomeProject/db"
 	"awesomeProject/models"
+	"awesomeProject/utils"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"time"
@@ -13,7 +14,7 @@ func SearchGames(c *gin.Context) {
 	query := c.Query("q")
 
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgSearchQueryRequired)
 		return
 	}
 
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+var (
+	errSeriesGameNotFound = errors.New("series game not found")
+	errGameInOtherSeries  = errors.New("game already belongs to another series")
+	errSeriesGameDenied   = errors.New("series game not manageable by user")
+)
+
+// seriesEntryView - a series entry with the caller's ownership state
+type seriesEntryView struct {
+	Position int         `json:"position"`
+	Game     models.Game `json:"game"`
+	Owned    bool        `json:"owned"`
+}
+
+// loadSeriesEntries returns the series entries in order with their games
+func loadSeriesEntries(seriesID uint) ([]models.SeriesEntry, error) {
+	var entries []models.SeriesEntry
+	err := db.DB.Where("series_id = ?", seriesID).
+		Preload("Game").
+		Order("position").
+		Find(&entries).Error
+	return entries, err
+}
+
+// seriesEntryViews attaches the user's ownership state to series entries
+func seriesEntryViews(userID uint, entries []models.SeriesEntry) []seriesEntryView {
+	gameIDs := make([]uint, len(entries))
+	for i, entry := range entries {
+		gameIDs[i] = entry.GameID
+	}
+	owned := ownedGameIDs(db.DB, userID, gameIDs)
+
+	views := make([]seriesEntryView, len(entries))
+	for i, entry := range entries {
+		views[i] = seriesEntryView{
+			Position: entry.Position,
+			Game:     entry.Game,
+			Owned:    owned[entry.GameID],
+		}
+	}
+	return views
+}
+
+// setSeriesEntries replaces the series entries with the given games in order
+func setSeriesEntries(tx *gorm.DB, user models.User, seriesID uint, gameIDs []uint) error {
+	gameIDs = uniqueIDs(gameIDs)
+
+	var games []models.Game
+	if err := tx.Where("id IN ?", gameIDs).Find(&games).Error; err != nil {
+		return err
+	}
+	if len(games) != len(gameIDs) {
+		return errSeriesGameNotFound
+	}
+	for _, game := range games {
+		if !canManageGame(user, game) {
+			return errSeriesGameDenied
+		}
+	}
+
+	var taken int64
+	if err := tx.Model(&models.SeriesEntry{}).
+		Where("game_id IN ? AND series_id <> ?", gameIDs, seriesID).
+		Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return errGameInOtherSeries
+	}
+
+	if err := tx.Where("series_id = ?", seriesID).Delete(&models.SeriesEntry{}).Error; err != nil {
+		return err
+	}
+	for i, gameID := range gameIDs {
+		entry := models.SeriesEntry{
+			SeriesID: seriesID,
+			GameID:   gameID,
+			Position: i + 1,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seriesEntriesError sends the response for a setSeriesEntries failure
+func seriesEntriesError(c *gin.Context, err error, fallback utils.MessageCode) {
+	switch {
+	case errors.Is(err, errSeriesGameNotFound):
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgGameNotFound)
+	case errors.Is(err, errSeriesGameDenied):
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgOwnGamesOnly)
+	case errors.Is(err, errGameInOtherSeries):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGameInOtherSeries)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// GetSeriesList - all series
+func GetSeriesList(c *gin.Context) {
+	var series []models.Series
+	if err := db.DB.Order("name").Find(&series).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchSeriesFailed)
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// GetSeriesByID - series with its games in order
+func GetSeriesByID(c *gin.Context) {
+	var series models.Series
+	if err := db.DB.First(&series, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgSeriesNotFound)
+		return
+	}
+
+	entries, err := loadSeriesEntries(series.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchSeriesFailed)
+		return
+	}
+	series.Entries = entries
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetSeriesProgress - series page with the titles the user owns and misses
+// GET /series/:id/progress
+func GetSeriesProgress(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var series models.Series
+	if err := db.DB.First(&series, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgSeriesNotFound)
+		return
+	}
+
+	entries, err := loadSeriesEntries(series.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchSeriesFailed)
+		return
+	}
+
+	views := seriesEntryViews(user.ID, entries)
+	missing := []models.Game{}
+	for _, view := range views {
+		if !view.Owned {
+			missing = append(missing, view.Game)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":        series,
+		"entries":       views,
+		"total":         len(views),
+		"total_owned":   len(views) - len(missing),
+		"total_missing": len(missing),
+		"missing_games": missing,
+	})
+}
+
+// CreateSeries - admins or developers, from games they manage
+func CreateSeries(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" && user.Role != "developer" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOrDevelopers)
+		return
+	}
+
+	var input models.SeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	series := models.Series{
+		Name:        input.Name,
+		Description: input.Description,
+		CreatedByID: user.ID,
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		if len(input.GameIDs) == 0 {
+			return nil
+		}
+		return setSeriesEntries(tx, user, series.ID, input.GameIDs)
+	})
+	if err != nil {
+		seriesEntriesError(c, err, utils.MsgCreateSeriesFailed)
+		return
+	}
+
+	series.Entries, _ = loadSeriesEntries(series.ID)
+	c.JSON(http.StatusOK, series)
+}
+
+// UpdateSeriesEntries - reorder or replace the games of a series
+// PUT /series/:id/entries
+func UpdateSeriesEntries(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var series models.Series
+	if err := db.DB.First(&series, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgSeriesNotFound)
+		return
+	}
+	if user.Role != "admin" && user.ID != series.CreatedByID {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
+		return
+	}
+
+	var input models.SeriesEntriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		return setSeriesEntries(tx, user, series.ID, input.GameIDs)
+	})
+	if err != nil {
+		seriesEntriesError(c, err, utils.MsgUpdateSeriesFailed)
+		return
+	}
+
+	series.Entries, _ = loadSeriesEntries(series.ID)
+	c.JSON(http.StatusOK, series)
+}
+
+// DeleteSeries - admins or the series creator; games are kept
+func DeleteSeries(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var series models.Series
+	if err := db.DB.First(&series, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgSeriesNotFound)
+		return
+	}
+	if user.Role != "admin" && user.ID != series.CreatedByID {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAccessDenied)
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&series).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgDeleteSeriesFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series deleted"})
+}
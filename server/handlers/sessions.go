@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"time"
+)
+
+// playSessionMaxGap is the most one heartbeat can add. Clients send one
+// every few minutes; a longer silence is counted as idle, not played.
+const playSessionMaxGap = 10 * time.Minute
+
+var errPlaySessionEnded = errors.New("play session already ended")
+
+// accruePlaytime counts the time since the session was last seen, up to
+// playSessionMaxGap, and adds the whole minutes it completes to the
+// ownership's playtime
+func accruePlaytime(tx *gorm.DB, session *models.PlaySession, now time.Time) error {
+	elapsed := now.Sub(session.LastSeenAt)
+	if elapsed > playSessionMaxGap {
+		elapsed = playSessionMaxGap
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	before := session.Seconds / 60
+	session.Seconds += int(elapsed / time.Second)
+	session.LastSeenAt = now
+	if minutes := session.Seconds/60 - before; minutes > 0 {
+		if err := tx.Model(&models.Ownership{}).
+			Where("user_id = ? AND game_id = ?", session.UserID, session.GameID).
+			Update("playtime_minutes", gorm.Expr("playtime_minutes + ?", minutes)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartPlaySession - start timing play of an owned game. Sessions the
+// client left open for the game are closed without counting more time.
+// POST /ownership/sessions
+func StartPlaySession(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input models.PlaySessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var session models.PlaySession
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var ownership models.Ownership
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND game_id = ? AND status = ?", user.ID, input.GameID, "owned").
+			First(&ownership).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.PlaySession{}).
+			Where("user_id = ? AND game_id = ? AND ended_at IS NULL", user.ID, input.GameID).
+			Update("ended_at", gorm.Expr("last_seen_at")).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		session = models.PlaySession{
+			UserID:     user.ID,
+			GameID:     input.GameID,
+			StartedAt:  now,
+			LastSeenAt: now,
+		}
+		return tx.Create(&session).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgOwnershipNotFound)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdatePlaytimeFailed)
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// touchPlaySession counts the time played since the last call and, with
+// end set, closes the session
+func touchPlaySession(c *gin.Context, end bool) {
+	user := c.MustGet("user").(models.User)
+
+	var session models.PlaySession
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", c.Param("id"), user.ID).
+			First(&session).Error; err != nil {
+			return err
+		}
+		if session.EndedAt != nil {
+			return errPlaySessionEnded
+		}
+
+		now := time.Now()
+		if err := accruePlaytime(tx, &session, now); err != nil {
+			return err
+		}
+		if end {
+			session.EndedAt = &now
+		}
+		return tx.Select("LastSeenAt", "Seconds", "EndedAt").Save(&session).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgPlaySessionNotFound)
+	case errors.Is(err, errPlaySessionEnded):
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgPlaySessionEnded)
+	case err != nil:
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdatePlaytimeFailed)
+	default:
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+// HeartbeatPlaySession - the game is still running
+// POST /ownership/sessions/:id/heartbeat
+func HeartbeatPlaySession(c *gin.Context) {
+	touchPlaySession(c, false)
+}
+
+// EndPlaySession - the game was closed
+// POST /ownership/sessions/:id/end
+func EndPlaySession(c *gin.Context) {
+	touchPlaySession(c, true)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+)
+
+var (
+	errInvalidSlug = errors.New("invalid slug")
+	errSlugTaken   = errors.New("slug already in use")
+)
+
+// slugTables maps slug entities to their tables
+var slugTables = map[string]string{
+	models.SlugEntityGame:     "games",
+	models.SlugEntityCategory: "categories",
+}
+
+// pickSlug returns the slug for an entity row. An explicitly requested slug
+// must be well-formed, not only digits, and free; otherwise one is derived
+// from the name.
+func pickSlug(tx *gorm.DB, entity string, id uint, requested, name string) (string, error) {
+	table := slugTables[entity]
+
+	if requested != "" {
+		if utils.Slugify(requested) != requested || utils.IsNumericSlug(requested) {
+			return "", errInvalidSlug
+		}
+		slug, err := db.UniqueSlug(tx, table, requested, id)
+		if err != nil {
+			return "", err
+		}
+		if slug != requested {
+			return "", errSlugTaken
+		}
+		return slug, nil
+	}
+
+	base := utils.Slugify(name)
+	switch {
+	case base == "":
+		base = entity
+	case utils.IsNumericSlug(base):
+		// "2048" would be read as an ID, so it becomes "2048-game"
+		base += "-" + entity
+	}
+	return db.UniqueSlug(tx, table, base, id)
+}
+
+// moveSlug keeps oldSlug working as a redirect to the entity and frees
+// newSlug from redirects that pointed elsewhere
+func moveSlug(tx *gorm.DB, entity string, id uint, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+	if err := tx.Where("entity = ? AND slug = ?", entity, newSlug).Delete(&models.SlugRedirect{}).Error; err != nil {
+		return err
+	}
+	if oldSlug == "" {
+		return nil
+	}
+
+	redirect := models.SlugRedirect{Entity: entity, Slug: oldSlug, TargetID: id}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity"}, {Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"target_id"}),
+	}).Create(&redirect).Error
+}
+
+// resolveSlugRef maps an ID or slug to the entity ID. For an old slug it
+// also returns the entity's current slug so the caller can redirect.
+func resolveSlugRef(entity, ref string) (uint, string, error) {
+	if id, err := strconv.Atoi(ref); err == nil && id > 0 {
+		return uint(id), "", nil
+	}
+
+	if cache.IsRedisAvailable() {
+		if id, err := cache.GetSlugID(entity, ref); err == nil && id != 0 {
+			return id, "", nil
+		}
+	}
+
+	table := slugTables[entity]
+	var id uint
+	if err := db.DB.Table(table).Select("id").Where("slug = ?", ref).Scan(&id).Error; err != nil {
+		return 0, "", err
+	}
+	if id != 0 {
+		if cache.IsRedisAvailable() {
+			cache.SetSlugID(entity, ref, id)
+		}
+		return id, "", nil
+	}
+
+	var redirect models.SlugRedirect
+	if err := db.DB.Where("entity = ? AND slug = ?", entity, ref).First(&redirect).Error; err != nil {
+		return 0, "", err
+	}
+	var current string
+	if err := db.DB.Table(table).Select("slug").Where("id = ?", redirect.TargetID).Scan(&current).Error; err != nil {
+		return 0, "", err
+	}
+	if current == "" {
+		return 0, "", fmt.Errorf("%s %d: %w", entity, redirect.TargetID, gorm.ErrRecordNotFound)
+	}
+	return redirect.TargetID, current, nil
+}
+
+// slugErrorCode maps pickSlug errors to message codes
+func slugErrorCode(err error) utils.MessageCode {
+	switch {
+	case errors.Is(err, errInvalidSlug):
+		return utils.MsgInvalidSlug
+	case errors.Is(err, errSlugTaken):
+		return utils.MsgSlugTaken
+	default:
+		return ""
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"awesomeProject/db"
 	"awesomeProject/models"
+	"awesomeProject/utils"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"time"
@@ -13,7 +14,7 @@ import (
 func GetDashboardStats(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
 
@@ -34,7 +35,7 @@ func GetDashboardStats(c *gin.Context) {
 	db.DB.Model(&models.Review{}).Count(&totalReviews)
 
 	// Count sales
-	db.DB.Model(&models.Ownership{}).Count(&totalSales)
+	db.DB.Model(&models.Ownership{}).Where("status = ?", "owned").Count(&totalSales)
 
 	// Count active users (not banned)
 	db.DB.Model(&models.User{}).Where("is_banned = ?", false).Count(&activeUsers)
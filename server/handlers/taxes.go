@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// validCountryCode reports whether code looks like an ISO 3166 alpha-2 code
+func validCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// findTaxRule returns the active rule for the location; a rule for the
+// region wins over the country-wide one
+func findTaxRule(tx *gorm.DB, country, region string) (models.TaxRule, bool, error) {
+	var rules []models.TaxRule
+	err := tx.Where("country = ? AND region IN ? AND active = ?", country, []string{region, ""}, true).
+		Order("region DESC").Limit(1).Find(&rules).Error
+	if err != nil || len(rules) == 0 {
+		return models.TaxRule{}, false, err
+	}
+	return rules[0], true, nil
+}
+
+// applyTax works out the tax of every item from the buyer's billing
+// location and records the rule on the order
+func applyTax(tx *gorm.DB, buyer models.User, order *models.Order) error {
+	if buyer.BillingCountry == "" {
+		return nil
+	}
+	rule, found, err := findTaxRule(tx, buyer.BillingCountry, buyer.BillingRegion)
+	if err != nil || !found {
+		return err
+	}
+
+	order.Tax = 0
+	for i := range order.Items {
+		item := &order.Items[i]
+		if rule.Inclusive {
+			item.Tax = item.Price * rule.Rate / (100 + rule.Rate)
+		} else {
+			item.Tax = item.Price * rule.Rate / 100
+		}
+		item.Tax = math.Round(item.Tax*100) / 100
+		order.Tax += item.Tax
+	}
+
+	order.Tax = math.Round(order.Tax*100) / 100
+	order.TaxName = rule.Name
+	order.TaxRate = rule.Rate
+	order.TaxInclusive = rule.Inclusive
+	order.TaxCountry = rule.Country
+	order.TaxRegion = rule.Region
+	return nil
+}
+
+// bindTaxRuleInput validates a tax rule body
+func bindTaxRuleInput(c *gin.Context) (models.TaxRuleInput, bool) {
+	var input models.TaxRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return input, false
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return input, false
+	}
+	input.Country = strings.ToUpper(input.Country)
+	input.Region = strings.ToUpper(strings.TrimSpace(input.Region))
+	return input, true
+}
+
+// taxRuleExists reports whether another rule covers the same jurisdiction
+func taxRuleExists(country, region string, exceptID uint) bool {
+	var count int64
+	db.DB.Model(&models.TaxRule{}).Where("country = ? AND region = ? AND id <> ?", country, region, exceptID).Count(&count)
+	return count > 0
+}
+
+// GetTaxRules - all tax rules by jurisdiction
+// GET /admin/tax-rules
+func GetTaxRules(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var rules []models.TaxRule
+	if err := db.DB.Order("country, region").Find(&rules).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchTaxRulesFailed)
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateTaxRule - add the tax of a country or region
+// POST /admin/tax-rules
+func CreateTaxRule(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	input, ok := bindTaxRuleInput(c)
+	if !ok {
+		return
+	}
+	if taxRuleExists(input.Country, input.Region, 0) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgTaxRuleExists)
+		return
+	}
+
+	rule := models.TaxRule{
+		Country:   input.Country,
+		Region:    input.Region,
+		Name:      input.Name,
+		Rate:      input.Rate,
+		Inclusive: input.Inclusive,
+		Active:    input.Active == nil || *input.Active,
+	}
+	if err := db.DB.Create(&rule).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveTaxRuleFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Tax rule %s/%s (%.2f%%) created by admin %d", rule.Country, rule.Region, rule.Rate, user.ID))
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateTaxRule - change a tax rule; past orders keep the tax they were charged
+// PUT /admin/tax-rules/:id
+func UpdateTaxRule(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var rule models.TaxRule
+	if err := db.DB.First(&rule, c.Param("id")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgTaxRuleNotFound)
+		return
+	}
+
+	input, ok := bindTaxRuleInput(c)
+	if !ok {
+		return
+	}
+	if taxRuleExists(input.Country, input.Region, rule.ID) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgTaxRuleExists)
+		return
+	}
+
+	rule.Country = input.Country
+	rule.Region = input.Region
+	rule.Name = input.Name
+	rule.Rate = input.Rate
+	rule.Inclusive = input.Inclusive
+	if input.Active != nil {
+		rule.Active = *input.Active
+	}
+	if err := db.DB.Save(&rule).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveTaxRuleFailed)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteTaxRule - remove a tax rule
+// DELETE /admin/tax-rules/:id
+func DeleteTaxRule(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	result := db.DB.Delete(&models.TaxRule{}, c.Param("id"))
+	if result.Error != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveTaxRuleFailed)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgTaxRuleNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tax rule deleted"})
+}
+
+// taxReportRow - sales and tax of one jurisdiction in one period
+type taxReportRow struct {
+	Country  string    `json:"country"`
+	Region   string    `json:"region"`
+	Period   time.Time `json:"period"`
+	Orders   int64     `json:"orders"`
+	NetSales float64   `json:"netSales"`
+	Tax      float64   `json:"tax"`
+}
+
+var errInvalidDateRange = errors.New("invalid date range")
+
+// reportDateRange reads ?from= and ?to= (YYYY-MM-DD, to exclusive).
+// Defaults to the current year so far.
+func reportDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	to := now
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return from, to, errInvalidDateRange
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return from, to, errInvalidDateRange
+		}
+		to = parsed
+	}
+	if !from.Before(to) {
+		return from, to, errInvalidDateRange
+	}
+	return from, to, nil
+}
+
+// GetTaxReport - tax collected per jurisdiction and period. Refunded items
+// are left out; orders without a tax rule show up with an empty country.
+// GET /admin/reports/tax?from=2026-01-01&to=2026-04-01&period=month
+func GetTaxReport(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+	if user.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	period := c.DefaultQuery("period", "month")
+	switch period {
+	case "day", "month", "quarter", "year":
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidReportPeriod)
+		return
+	}
+	from, to, err := reportDateRange(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidDateRange)
+		return
+	}
+
+	var rows []taxReportRow
+	err = db.DB.Model(&models.OrderItem{}).
+		Select(`orders.tax_country AS country, orders.tax_region AS region,
+			date_trunc(?, orders.paid_at) AS period,
+			COUNT(DISTINCT orders.id) AS orders,
+			SUM(CASE WHEN orders.tax_inclusive THEN order_items.price - order_items.tax ELSE order_items.price END) AS net_sales,
+			SUM(order_items.tax) AS tax`, period).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.status IN ? AND order_items.refunded_at IS NULL AND orders.paid_at >= ? AND orders.paid_at < ?",
+			[]string{models.OrderStatusPaid, models.OrderStatusRefunded}, from, to).
+		// By position, Postgres sees a second date_trunc(?, ...) as a
+		// different expression
+		Group("1, 2, 3").
+		Order("period, country, region").
+		Scan(&rows).Error
+	if err != nil {
+		utils.LogError("Tax report failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgTaxReportFailed)
+		return
+	}
+
+	var totalTax, totalSales float64
+	for i := range rows {
+		rows[i].NetSales = math.Round(rows[i].NetSales*100) / 100
+		rows[i].Tax = math.Round(rows[i].Tax*100) / 100
+		totalTax += rows[i].Tax
+		totalSales += rows[i].NetSales
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from,
+		"to":       to,
+		"period":   period,
+		"rows":     rows,
+		"netSales": math.Round(totalSales*100) / 100,
+		"tax":      math.Round(totalTax*100) / 100,
+	})
+}
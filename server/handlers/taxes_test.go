@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetTaxReport(t *testing.T) {
+	openTestDB(t)
+	// The report sums every order in the range, so the test's orders are
+	// rolled back and can't show up in later runs
+	tx := db.DB.Begin()
+	defer tx.Rollback()
+	db.DB = tx
+	if err := tx.Exec("SET LOCAL TIME ZONE 'UTC'").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	admin := models.User{Email: fmt.Sprintf("admin-%d@example.com", time.Now().UnixNano()), Password: "x", Name: "Admin", Role: "admin"}
+	if err := db.DB.Create(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	jan := time.Date(2001, 1, 15, 10, 0, 0, 0, time.UTC)
+	feb := time.Date(2001, 2, 3, 10, 0, 0, 0, time.UTC)
+	refunded := jan.Add(time.Hour)
+	orders := []models.Order{
+		{Status: models.OrderStatusPaid, PaidAt: &jan, TaxCountry: "DE", Items: []models.OrderItem{
+			{Name: "A", Price: 11.9, Tax: 1.9},
+			{Name: "B", Price: 23.8, Tax: 3.8, RefundedAt: &refunded},
+		}, TaxInclusive: true},
+		{Status: models.OrderStatusPaid, PaidAt: &feb, TaxCountry: "DE", Items: []models.OrderItem{
+			{Name: "C", Price: 5.95, Tax: 0.95},
+		}, TaxInclusive: true},
+		{Status: models.OrderStatusPaid, PaidAt: &jan, TaxCountry: "US", TaxRegion: "CA", Items: []models.OrderItem{
+			{Name: "D", Price: 10, Tax: 0.73},
+			{Name: "E", Price: 20, Tax: 1.45},
+		}},
+		{Status: models.OrderStatusPending, TaxCountry: "US", Items: []models.OrderItem{
+			{Name: "F", Price: 99, Tax: 7},
+		}},
+	}
+	for i := range orders {
+		orders[i].UserID = admin.ID
+		orders[i].Provider = "fake"
+		if err := db.DB.Create(&orders[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := gin.New()
+	router.GET("/admin/reports/tax", func(c *gin.Context) {
+		c.Set("user", admin)
+		GetTaxReport(c)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/admin/reports/tax?from=2001-01-01&to=2002-01-01&period=month", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var report struct {
+		Rows     []taxReportRow `json:"rows"`
+		NetSales float64        `json:"netSales"`
+		Tax      float64        `json:"tax"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []taxReportRow{
+		{Country: "DE", Period: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), Orders: 1, NetSales: 10, Tax: 1.9},
+		{Country: "US", Region: "CA", Period: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), Orders: 1, NetSales: 30, Tax: 2.18},
+		{Country: "DE", Period: time.Date(2001, 2, 1, 0, 0, 0, 0, time.UTC), Orders: 1, NetSales: 5, Tax: 0.95},
+	}
+	if len(report.Rows) != len(want) {
+		t.Fatalf("rows = %+v, want %+v", report.Rows, want)
+	}
+	for i, row := range report.Rows {
+		w := want[i]
+		if row.Country != w.Country || row.Region != w.Region || !row.Period.Equal(w.Period) ||
+			row.Orders != w.Orders || row.NetSales != w.NetSales || row.Tax != w.Tax {
+			t.Errorf("row %d = %+v, want %+v", i, row, w)
+		}
+	}
+	if report.NetSales != 45 || report.Tax != 5.03 {
+		t.Errorf("totals = %v net, %v tax, want 45 and 5.03", report.NetSales, report.Tax)
+	}
+}
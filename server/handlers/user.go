@@ -10,18 +10,19 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // GetUsers - admins only
 func GetUsers(c *gin.Context) {
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
 	var users []models.User
 	if err := db.DB.Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchUsersFailed)
 		return
 	}
 	c.JSON(http.StatusOK, users)
@@ -32,14 +33,14 @@ func GetUserByID(c *gin.Context) {
 	id := c.Param("id")
 	userID, err := strconv.Atoi(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidUserID)
 		return
 	}
 
 	currentUser := c.MustGet("user").(models.User)
 
 	if currentUser.Role != "admin" && currentUser.ID != uint(userID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgForbidden)
 		return
 	}
 
@@ -58,7 +59,7 @@ func GetUserByID(c *gin.Context) {
 	var user models.User
 	if err := db.DB.First(&user, userID).Error; err != nil {
 		log.Printf("User not found: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgUserNotFound)
 		return
 	}
 
@@ -77,18 +78,18 @@ func DeleteUser(c *gin.Context) {
 
 	user := c.MustGet("user").(models.User)
 	if user.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
 
 	var targetUser models.User
 	if err := db.DB.First(&targetUser, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgUserNotFound)
 		return
 	}
 
 	if err := db.DB.Delete(&targetUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgDeleteUserFailed)
 		return
 	}
 
@@ -112,14 +113,14 @@ func UpdateUser(c *gin.Context) {
 	log.Printf("Updating user ID: %s by user ID: %d, role: %s", id, currentUser.ID, currentUser.Role)
 
 	if currentUser.Role != "admin" && currentUser.ID != uint(userID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgForbidden)
 		return
 	}
 
 	var targetUser models.User
 	if err := db.DB.First(&targetUser, userID).Error; err != nil {
 		log.Printf("User not found: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgUserNotFound)
 		return
 	}
 
@@ -129,7 +130,7 @@ func UpdateUser(c *gin.Context) {
 		log.Printf("Saving avatar to: %s", filename)
 		if err := c.SaveUploadedFile(file, filename); err != nil {
 			log.Printf("Failed to save avatar: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+			utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgSaveAvatarFailed)
 			return
 		}
 		targetUser.Avatar = filename
@@ -140,10 +141,13 @@ func UpdateUser(c *gin.Context) {
 		Name     *string `form:"name"`
 		Role     *string `form:"role"`
 		IsBanned *bool   `form:"isBanned"`
+
+		BillingCountry *string `form:"billingCountry"`
+		BillingRegion  *string `form:"billingRegion"`
 	}
 	if err := c.ShouldBind(&input); err != nil {
 		log.Printf("Invalid input: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.BadRequest(c, err)
 		return
 	}
 
@@ -158,9 +162,21 @@ func UpdateUser(c *gin.Context) {
 		targetUser.IsBanned = *input.IsBanned
 	}
 
+	if input.BillingCountry != nil {
+		country := strings.ToUpper(strings.TrimSpace(*input.BillingCountry))
+		if country != "" && !validCountryCode(country) {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidBillingCountry)
+			return
+		}
+		targetUser.BillingCountry = country
+	}
+	if input.BillingRegion != nil {
+		targetUser.BillingRegion = strings.ToUpper(strings.TrimSpace(*input.BillingRegion))
+	}
+
 	if err := db.DB.Save(&targetUser).Error; err != nil {
 		log.Printf("Failed to update user: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateUserFailed)
 		return
 	}
 
@@ -181,19 +197,19 @@ func BanUser(c *gin.Context) {
 	currentUser := c.MustGet("user").(models.User)
 
 	if currentUser.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
 
 	var targetUser models.User
 	if err := db.DB.First(&targetUser, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgUserNotFound)
 		return
 	}
 
 	targetUser.IsBanned = true
 	if err := db.DB.Save(&targetUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgBanUserFailed)
 		return
 	}
 
@@ -213,19 +229,19 @@ func UnbanUser(c *gin.Context) {
 	currentUser := c.MustGet("user").(models.User)
 
 	if currentUser.Role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admins only"})
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
 		return
 	}
 
 	var targetUser models.User
 	if err := db.DB.First(&targetUser, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgUserNotFound)
 		return
 	}
 
 	targetUser.IsBanned = false
 	if err := db.DB.Save(&targetUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unban user"})
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUnbanUserFailed)
 		return
 	}
 
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/payments"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errInsufficientFunds = errors.New("insufficient wallet funds")
+
+// ensureWallet returns the user's wallet, creating it on first use
+func ensureWallet(tx *gorm.DB, userID uint) (models.Wallet, error) {
+	var wallet models.Wallet
+	err := tx.Where(models.Wallet{UserID: userID}).FirstOrCreate(&wallet).Error
+	return wallet, err
+}
+
+// walletBalance sums the wallet's ledger
+func walletBalance(tx *gorm.DB, walletID uint) (float64, error) {
+	var balance float64
+	err := tx.Model(&models.WalletEntry{}).
+		Where("wallet_id = ?", walletID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&balance).Error
+	return balance, err
+}
+
+// creditWallet appends a credit to the user's wallet
+func creditWallet(tx *gorm.DB, userID uint, amount float64, reason, reference string, createdByID *uint) (models.WalletEntry, error) {
+	wallet, err := ensureWallet(tx, userID)
+	if err != nil {
+		return models.WalletEntry{}, err
+	}
+
+	entry := models.WalletEntry{
+		WalletID:    wallet.ID,
+		Amount:      math.Round(amount*100) / 100,
+		Reason:      reason,
+		Reference:   reference,
+		CreatedByID: createdByID,
+	}
+	return entry, tx.Create(&entry).Error
+}
+
+// debitWallet appends a debit to the user's wallet. The wallet row is locked
+// while the balance is checked, so concurrent debits can't overdraw it.
+func debitWallet(userID uint, amount float64, reason, reference string, createdByID *uint) (models.WalletEntry, error) {
+	var entry models.WalletEntry
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		wallet, err := ensureWallet(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, wallet.ID).Error; err != nil {
+			return err
+		}
+
+		balance, err := walletBalance(tx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		amount = math.Round(amount*100) / 100
+		if balance < amount {
+			return errInsufficientFunds
+		}
+
+		entry = models.WalletEntry{
+			WalletID:    wallet.ID,
+			Amount:      -amount,
+			Reason:      reason,
+			Reference:   reference,
+			CreatedByID: createdByID,
+		}
+		return tx.Create(&entry).Error
+	})
+	return entry, err
+}
+
+// walletProvider pays orders from store credit
+type walletProvider struct{}
+
+// WalletProvider returns the payment provider backed by user wallets
+func WalletProvider() payments.Provider {
+	return walletProvider{}
+}
+
+// Name of the provider
+func (walletProvider) Name() string {
+	return "wallet"
+}
+
+// Charge debits the buyer's wallet; the payment settles at once
+func (walletProvider) Charge(charge payments.Charge) (payments.Payment, error) {
+	entry, err := debitWallet(charge.UserID, charge.Amount, models.WalletReasonPurchase, fmt.Sprintf("order:%d", charge.OrderID), nil)
+	if err != nil {
+		return payments.Payment{}, err
+	}
+	return payments.Payment{
+		Reference: fmt.Sprintf("wallet:%d", entry.ID),
+		Status:    payments.StatusPaid,
+	}, nil
+}
+
+// Refund credits the amount back to the wallet the payment came from
+func (p walletProvider) Refund(reference string, amount float64) error {
+	return p.RefundTx(db.DB, reference, amount)
+}
+
+// RefundTx is Refund within the caller's transaction, so the credit rolls
+// back with the refund it belongs to
+func (walletProvider) RefundTx(tx *gorm.DB, reference string, amount float64) error {
+	entryID, err := strconv.Atoi(strings.TrimPrefix(reference, "wallet:"))
+	if err != nil {
+		return fmt.Errorf("invalid wallet payment reference %q", reference)
+	}
+
+	var debit models.WalletEntry
+	if err := tx.First(&debit, entryID).Error; err != nil {
+		return err
+	}
+	var wallet models.Wallet
+	if err := tx.First(&wallet, debit.WalletID).Error; err != nil {
+		return err
+	}
+
+	_, err = creditWallet(tx, wallet.UserID, amount, models.WalletReasonRefund, reference, nil)
+	return err
+}
+
+// ParseWebhook - wallet payments settle synchronously, there are no webhooks
+func (walletProvider) ParseWebhook(payload []byte, signature string) (payments.WebhookEvent, error) {
+	return payments.WebhookEvent{}, errors.New("wallet payments have no webhooks")
+}
+
+// GetWallet - the current user's balance and latest ledger entries
+// GET /wallet?limit=50
+func GetWallet(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	wallet, err := ensureWallet(db.DB, user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchWalletFailed)
+		return
+	}
+	balance, err := walletBalance(db.DB, wallet.ID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchWalletFailed)
+		return
+	}
+
+	var entries []models.WalletEntry
+	if err := db.DB.Where("wallet_id = ?", wallet.ID).Order("id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchWalletFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"balance": balance,
+		"entries": entries,
+	})
+}
+
+// AddWalletEntry - admins credit store credit (gift cards, promotions) or
+// adjust a balance; negative amounts are debits
+// POST /admin/wallets/:userId/entries
+func AddWalletEntry(c *gin.Context) {
+	admin := c.MustGet("user").(models.User)
+	if admin.Role != "admin" {
+		utils.ErrorResponse(c, http.StatusForbidden, utils.MsgAdminsOnly)
+		return
+	}
+
+	var target models.User
+	if err := db.DB.First(&target, c.Param("userId")).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgUserNotFound)
+		return
+	}
+
+	var input models.WalletEntryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var entry models.WalletEntry
+	var err error
+	if input.Amount > 0 {
+		entry, err = creditWallet(db.DB, target.ID, input.Amount, input.Reason, input.Reference, &admin.ID)
+	} else {
+		entry, err = debitWallet(target.ID, -input.Amount, input.Reason, input.Reference, &admin.ID)
+	}
+	if errors.Is(err, errInsufficientFunds) {
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgInsufficientFunds)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateWalletFailed)
+		return
+	}
+
+	utils.Log.Info(fmt.Sprintf("Wallet of user %d changed by %.2f (%s) by admin %d", target.ID, entry.Amount, entry.Reason, admin.ID))
+	c.JSON(http.StatusOK, entry)
+}
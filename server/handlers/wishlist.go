@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"awesomeProject/db"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// releaseAlertWindow is how long after its release date a game still
+// triggers release alerts, so old games don't alert new wishlisters
+const releaseAlertWindow = 7 * 24 * time.Hour
+
+var errNotWishlisted = errors.New("game is not on the wishlist")
+
+// wishlistEntry - a wishlisted game with its current store state
+type wishlistEntry struct {
+	models.Ownership
+	OnSale         bool    `json:"onSale"`
+	EffectivePrice float64 `json:"effectivePrice"`
+	Released       bool    `json:"released"`
+}
+
+// wishlistedGames loads the user's wishlist in their order
+func wishlistedGames(tx *gorm.DB, userID uint) ([]models.Ownership, error) {
+	var entries []models.Ownership
+	err := tx.Where("user_id = ? AND status = ?", userID, "wishlisted").
+		Order("wishlist_position, id").Find(&entries).Error
+	return entries, err
+}
+
+// renumberWishlist stores the entries' order as positions 1..n
+func renumberWishlist(tx *gorm.DB, entries []models.Ownership) error {
+	for i, entry := range entries {
+		if entry.WishlistPosition == i+1 {
+			continue
+		}
+		if err := tx.Model(&models.Ownership{}).Where("id = ?", entry.ID).
+			Update("wishlist_position", i+1).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeWishlistEntry drops a wishlist entry. Games that were owned and
+// refunded before go back to "refunded" so their history is kept.
+func removeWishlistEntry(tx *gorm.DB, ownership models.Ownership) error {
+	if ownership.AcquiredAt == nil {
+		return tx.Delete(&ownership).Error
+	}
+	return tx.Model(&ownership).Updates(map[string]interface{}{
+		"status":            "refunded",
+		"wishlist_position": 0,
+	}).Error
+}
+
+// GetWishlist - the current user's wishlist
+// GET /wishlist?sort=position|added|name|price
+func GetWishlist(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var ownerships []models.Ownership
+	if err := db.DB.Where("user_id = ? AND status = ?", user.ID, "wishlisted").
+		Preload("Game").Order("wishlist_position, id").Find(&ownerships).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgFetchWishlistFailed)
+		return
+	}
+
+	now := time.Now()
+	entries := make([]wishlistEntry, len(ownerships))
+	for i, ownership := range ownerships {
+		entries[i] = wishlistEntry{
+			Ownership:      ownership,
+			OnSale:         ownership.Game.DiscountActive(now),
+			EffectivePrice: ownership.Game.EffectivePrice(now),
+			Released:       ownership.Game.Released(now),
+		}
+	}
+
+	switch c.DefaultQuery("sort", "position") {
+	case "added":
+		sort.SliceStable(entries, func(i, j int) bool {
+			a, b := entries[i].WishlistedAt, entries[j].WishlistedAt
+			return a != nil && (b == nil || a.After(*b))
+		})
+	case "name":
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Game.Name < entries[j].Game.Name
+		})
+	case "price":
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].EffectivePrice < entries[j].EffectivePrice
+		})
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// AddToWishlist - put a game at the end of the wishlist
+// POST /wishlist
+func AddToWishlist(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input models.WishlistInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var game models.Game
+	if err := db.DB.First(&game, input.GameID).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgGameNotFound)
+		return
+	}
+
+	var ownership models.Ownership
+	err := db.DB.Where("user_id = ? AND game_id = ?", user.ID, game.ID).First(&ownership).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateWishlistFailed)
+		return
+	}
+	switch ownership.Status {
+	case "owned":
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgGameAlreadyOwned)
+		return
+	case "wishlisted":
+		utils.ErrorResponse(c, http.StatusConflict, utils.MsgAlreadyWishlisted)
+		return
+	}
+
+	var last int
+	if err := db.DB.Model(&models.Ownership{}).
+		Where("user_id = ? AND status = ?", user.ID, "wishlisted").
+		Select("COALESCE(MAX(wishlist_position), 0)").Scan(&last).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateWishlistFailed)
+		return
+	}
+
+	now := time.Now()
+	ownership.UserID = user.ID
+	ownership.GameID = game.ID
+	ownership.Status = "wishlisted"
+	ownership.WishlistedAt = &now
+	ownership.WishlistPosition = last + 1
+	ownership.WishlistNote = input.Note
+	// A refunded game keeps its row and purchase history
+	if err := db.DB.Omit("Game").Save(&ownership).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateWishlistFailed)
+		return
+	}
+
+	ownership.Game = game
+	c.JSON(http.StatusCreated, ownership)
+}
+
+// UpdateWishlistEntry - change the note of an entry or move it
+// PUT /wishlist/:gameId
+func UpdateWishlistEntry(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	gameID, err := strconv.Atoi(c.Param("gameId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidGameID)
+		return
+	}
+
+	var input models.WishlistUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	var entry models.Ownership
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		entries, err := wishlistedGames(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		index := -1
+		for i := range entries {
+			if entries[i].GameID == uint(gameID) {
+				index = i
+			}
+		}
+		if index < 0 {
+			return errNotWishlisted
+		}
+		entry = entries[index]
+
+		if input.Note != nil {
+			entry.WishlistNote = *input.Note
+			if err := tx.Model(&entry).Update("wishlist_note", entry.WishlistNote).Error; err != nil {
+				return err
+			}
+		}
+		if input.Position == nil {
+			return nil
+		}
+
+		target := *input.Position - 1
+		if target >= len(entries) {
+			target = len(entries) - 1
+		}
+		entries = append(entries[:index], entries[index+1:]...)
+		entries = append(entries[:target], append([]models.Ownership{entry}, entries[target:]...)...)
+		entry.WishlistPosition = target + 1
+		return renumberWishlist(tx, entries)
+	})
+	if errors.Is(err, errNotWishlisted) {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgWishlistEntryNotFound)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateWishlistFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// ReorderWishlist - set the order of the wishlist at once
+// PUT /wishlist/order
+func ReorderWishlist(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var input models.WishlistOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequest(c, err)
+		return
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		entries, err := wishlistedGames(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		byGame := make(map[uint]models.Ownership, len(entries))
+		for _, entry := range entries {
+			byGame[entry.GameID] = entry
+		}
+
+		ordered := make([]models.Ownership, 0, len(entries))
+		placed := make(map[uint]bool, len(input.GameIDs))
+		for _, id := range input.GameIDs {
+			entry, found := byGame[id]
+			if !found {
+				return errNotWishlisted
+			}
+			if !placed[id] {
+				ordered = append(ordered, entry)
+				placed[id] = true
+			}
+		}
+		for _, entry := range entries {
+			if !placed[entry.GameID] {
+				ordered = append(ordered, entry)
+			}
+		}
+		return renumberWishlist(tx, ordered)
+	})
+	if errors.Is(err, errNotWishlisted) {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgWishlistEntryNotFound)
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateWishlistFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Wishlist reordered"})
+}
+
+// RemoveFromWishlist - take a game off the wishlist
+// DELETE /wishlist/:gameId
+func RemoveFromWishlist(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	gameID, err := strconv.Atoi(c.Param("gameId"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidGameID)
+		return
+	}
+
+	var ownership models.Ownership
+	if err := db.DB.Where("user_id = ? AND game_id = ? AND status = ?", user.ID, gameID, "wishlisted").First(&ownership).Error; err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, utils.MsgWishlistEntryNotFound)
+		return
+	}
+	if err := removeWishlistEntry(db.DB, ownership); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, utils.MsgUpdateWishlistFailed)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Removed from wishlist"})
+}
+
+// notifyUsers leaves the same notification about a game for each user
+func notifyUsers(tx *gorm.DB, userIDs []uint, kind string, gameID uint, message string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	notifications := make([]models.Notification, len(userIDs))
+	for i, userID := range userIDs {
+		notifications[i] = models.Notification{
+			UserID:  userID,
+			Type:    kind,
+			GameID:  &gameID,
+			Message: message,
+		}
+	}
+	return tx.CreateInBatches(&notifications, 500).Error
+}
+
+// notifyPriceDrop tells wishlisters when a change makes the game cheaper,
+// whether through its price or a discount. Every path that changes prices
+// calls it once the change is committed.
+func notifyPriceDrop(before models.GameSnapshot, game models.Game) {
+	var previous models.Game
+	before.ApplyTo(&previous)
+	now := time.Now()
+	price := game.EffectivePrice(now)
+	if price >= previous.EffectivePrice(now) {
+		return
+	}
+
+	var userIDs []uint
+	err := db.DB.Model(&models.Ownership{}).
+		Where("game_id = ? AND status = ?", game.ID, "wishlisted").
+		Pluck("user_id", &userIDs).Error
+	if err == nil {
+		message := fmt.Sprintf("%s from your wishlist dropped to %.2f", game.Name, price)
+		if game.DiscountActive(now) {
+			message = fmt.Sprintf("%s from your wishlist is %d%% off, now %.2f", game.Name, game.DiscountPercent, price)
+		}
+		err = notifyUsers(db.DB, userIDs, models.NotificationWishlistSale, game.ID, message)
+	}
+	if err != nil {
+		utils.LogError("Failed to send wishlist price alerts", map[string]interface{}{
+			"game_id": game.ID,
+			"error":   err.Error(),
+		})
+		return
+	}
+	utils.Log.Info(fmt.Sprintf("Price drop of game %d announced to %d wishlisters", game.ID, len(userIDs)))
+}
+
+// sendReleaseAlerts notifies wishlisters of games released recently. Users
+// who were already told about a release are skipped, so it is safe to run
+// repeatedly.
+func sendReleaseAlerts(now time.Time) error {
+	var games []models.Game
+	if err := db.DB.Select("id", "name").
+		Where("release_date <= ? AND release_date > ?", now, now.Add(-releaseAlertWindow)).
+		Find(&games).Error; err != nil {
+		return err
+	}
+
+	for _, game := range games {
+		var userIDs []uint
+		if err := db.DB.Model(&models.Ownership{}).
+			Where("game_id = ? AND status = ?", game.ID, "wishlisted").
+			Where("NOT EXISTS (SELECT 1 FROM notifications WHERE notifications.user_id = ownerships.user_id AND notifications.game_id = ownerships.game_id AND notifications.type = ?)", models.NotificationWishlistRelease).
+			Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			continue
+		}
+		message := game.Name + " from your wishlist is out now"
+		if err := notifyUsers(db.DB, userIDs, models.NotificationWishlistRelease, game.ID, message); err != nil {
+			return err
+		}
+		utils.Log.Info(fmt.Sprintf("Release of game %d announced to %d wishlisters", game.ID, len(userIDs)))
+	}
+	return nil
+}
+
+// WatchReleases sends release alerts every interval; run it in a goroutine
+func WatchReleases(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		if err := sendReleaseAlerts(now); err != nil {
+			utils.LogError("Failed to send wishlist release alerts", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package mail
+
+import (
+	"awesomeProject/utils"
+	"fmt"
+)
+
+// LogSender only logs messages; the default for development
+type LogSender struct{}
+
+// Name of the sender
+func (LogSender) Name() string {
+	return "log"
+}
+
+// Send logs the message instead of delivering it
+func (LogSender) Send(msg Message) error {
+	utils.Log.Info(fmt.Sprintf("Mail to %s: %q (%d attachments)", msg.To, msg.Subject, len(msg.Attachments)))
+	return nil
+}
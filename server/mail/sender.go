@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"errors"
+	"os"
+)
+
+// Attachment - a file sent along with a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message - an HTML email
+type Message struct {
+	To          string
+	Subject     string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Sender - a way of delivering email
+type Sender interface {
+	Name() string
+	Send(msg Message) error
+}
+
+var (
+	senders       = map[string]Sender{}
+	defaultSender = "log"
+)
+
+// Register makes a sender available by name. Call it during startup.
+func Register(s Sender) {
+	senders[s.Name()] = s
+}
+
+// Default returns the sender mail goes out through
+func Default() Sender {
+	return senders[defaultSender]
+}
+
+// Init registers the built-in senders and picks the default one from
+// MAIL_SENDER. The SMTP sender reads SMTP_HOST, SMTP_PORT, SMTP_USER,
+// SMTP_PASSWORD and MAIL_FROM.
+func Init() error {
+	Register(LogSender{})
+	Register(&SMTPSender{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USER"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+	})
+
+	if name := os.Getenv("MAIL_SENDER"); name != "" {
+		defaultSender = name
+	}
+	if _, ok := senders[defaultSender]; !ok {
+		return errors.New("unknown mail sender: " + defaultSender)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"awesomeProject/utils"
 	"crypto/rand"
 	"encoding/base64"
 	"github.com/gin-gonic/gin"
@@ -75,7 +76,7 @@ func CSRFProtection() gin.HandlerFunc {
 		}
 
 		if token == "" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token missing"})
+			utils.ErrorResponse(c, http.StatusForbidden, utils.MsgCSRFTokenMissing)
 			c.Abort()
 			return
 		}
@@ -86,7 +87,7 @@ func CSRFProtection() gin.HandlerFunc {
 		csrfMutex.RUnlock()
 
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid CSRF token"})
+			utils.ErrorResponse(c, http.StatusForbidden, utils.MsgCSRFTokenInvalid)
 			c.Abort()
 			return
 		}
@@ -97,7 +98,7 @@ func CSRFProtection() gin.HandlerFunc {
 			delete(csrfTokens, token)
 			csrfMutex.Unlock()
 
-			c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token expired"})
+			utils.ErrorResponse(c, http.StatusForbidden, utils.MsgCSRFTokenExpired)
 			c.Abort()
 			return
 		}
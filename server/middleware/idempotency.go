@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+// IdempotencyHeader is the header clients put a unique key per operation in
+const IdempotencyHeader = "Idempotency-Key"
+
+// cartTokenHeader identifies an anonymous cart, see handlers.CartTokenHeader
+const cartTokenHeader = "X-Cart-Token"
+
+// idempotencyRecord - a request seen under a key, and its response once done
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// idempotencyWriter keeps a copy of the response body
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes POST, PUT, PATCH and DELETE requests carrying an
+// Idempotency-Key safe to retry. The first response per user (or, for
+// guests, per address and cart token) and key is kept for ttl and replayed
+// for retries; reusing a key for a different request is rejected. Server
+// errors are not kept, so those can be retried for real. Without Redis, and
+// for guests without a cart yet, requests go through unprotected.
+// Put it after AuthMiddleware.
+func Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			key = ""
+		}
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			utils.ErrorResponse(c, http.StatusBadRequest, utils.MsgInvalidIdempotencyKey)
+			c.Abort()
+			return
+		}
+		if !cache.IsRedisAvailable() {
+			utils.LogWarn("Idempotency-Key ignored, Redis not available", map[string]interface{}{
+				"path": c.Request.URL.Path,
+			})
+			c.Next()
+			return
+		}
+
+		// Guests behind one address are told apart by their cart; without
+		// one there is nobody to keep the response for
+		var scope, cartToken string
+		if user, ok := c.Get("user"); ok {
+			scope = fmt.Sprintf("user:%d", user.(models.User).ID)
+		} else if cartToken = c.GetHeader(cartTokenHeader); cartToken != "" {
+			scope = "ip:" + c.ClientIP() + ":cart:" + cartToken
+		} else {
+			c.Next()
+			return
+		}
+		storeKey := cache.IdempotencyPrefix + scope + ":" + key
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				utils.BadRequest(c, err)
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n" + cartToken + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		claimed, err := cache.SetIfAbsent(storeKey, idempotencyRecord{Fingerprint: fingerprint}, ttl)
+		if err != nil {
+			utils.LogWarn("Idempotency-Key claim failed", map[string]interface{}{
+				"key":   storeKey,
+				"error": err.Error(),
+			})
+			c.Next()
+			return
+		}
+
+		if !claimed {
+			var record idempotencyRecord
+			if err := cache.Get(storeKey, &record); err != nil {
+				// Expired since the claim; treat the retry as in progress
+				utils.ErrorResponse(c, http.StatusConflict, utils.MsgIdempotencyInProgress)
+				c.Abort()
+				return
+			}
+			switch {
+			case record.Fingerprint != fingerprint:
+				utils.ErrorResponse(c, http.StatusUnprocessableEntity, utils.MsgIdempotencyKeyReused)
+			case !record.Done:
+				utils.ErrorResponse(c, http.StatusConflict, utils.MsgIdempotencyInProgress)
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.Status, record.ContentType, record.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		// Free the key again if the handler panics or fails
+		stored := false
+		defer func() {
+			if !stored {
+				cache.Delete(storeKey)
+			}
+		}()
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		record := idempotencyRecord{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		}
+		if err := cache.Set(storeKey, record, ttl); err != nil {
+			utils.LogWarn("Failed to store idempotent response", map[string]interface{}{
+				"key":   storeKey,
+				"error": err.Error(),
+			})
+			return
+		}
+		stored = true
+	}
+}
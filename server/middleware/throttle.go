@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"awesomeProject/cache"
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Throttle limits how often an endpoint can be called, counted both per
+// client IP and per authenticated user so neither many accounts nor many
+// addresses get around it. scope keeps the counters of endpoints apart.
+// Requests are refused while attempts can't be counted, so the limit never
+// turns itself off. Put it after AuthMiddleware.
+func Throttle(scope string, maxAttempts int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys := []string{fmt.Sprintf("%s:ip:%s", scope, c.ClientIP())}
+		if value, ok := c.Get("user"); ok {
+			if user, ok := value.(models.User); ok {
+				keys = append(keys, fmt.Sprintf("%s:user:%d", scope, user.ID))
+			}
+		}
+
+		for _, key := range keys {
+			allowed, err := cache.AllowAttempt(key, maxAttempts, window)
+			if err != nil {
+				utils.LogWarn("Throttle check failed", map[string]interface{}{
+					"key":   key,
+					"error": err.Error(),
+				})
+				utils.ErrorResponse(c, http.StatusServiceUnavailable, utils.MsgThrottleUnavailable)
+				c.Abort()
+				return
+			}
+			if !allowed {
+				c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
+				utils.ErrorResponse(c, http.StatusTooManyRequests, utils.MsgTooManyRequests)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// MaxKeysPerBatch caps how many keys one batch can hold
+const MaxKeysPerBatch = 5000
+
+// KeyBatch - a set of activation keys for one game, e.g. for an event or
+// a partner. MaxRedemptions, if set, caps how many of them can be redeemed.
+type KeyBatch struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	GameID         uint       `gorm:"not null;index" json:"gameId"`
+	Label          string     `gorm:"not null" json:"label"`
+	CreatedByID    uint       `gorm:"not null" json:"createdById"`
+	Count          int        `gorm:"not null" json:"count"`
+	MaxRedemptions *int       `json:"maxRedemptions,omitempty"`
+	Redeemed       int        `gorm:"not null;default:0" json:"redeemed"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// ActivationKey - a one-time key that grants ownership of a game
+type ActivationKey struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	BatchID      uint       `gorm:"not null;index" json:"batchId"`
+	GameID       uint       `gorm:"not null;index" json:"gameId"`
+	Game         Game       `gorm:"foreignKey:GameID" json:"game"`
+	Code         string     `gorm:"not null;uniqueIndex" json:"code"`
+	RedeemedByID *uint      `gorm:"index" json:"redeemedById,omitempty"`
+	RedeemedAt   *time.Time `json:"redeemedAt,omitempty"`
+}
+
+// KeyBatchInput - for generating a batch of keys
+type KeyBatchInput struct {
+	Label          string     `json:"label" validate:"required,min=1,max=100"`
+	Count          int        `json:"count" validate:"required,gte=1,lte=5000"`
+	MaxRedemptions *int       `json:"maxRedemptions" validate:"omitempty,gte=1"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+}
+
+// RedeemInput - a key typed in by the user
+type RedeemInput struct {
+	Key string `json:"key" validate:"required,min=5,max=64"`
+}
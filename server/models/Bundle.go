@@ -0,0 +1,19 @@
+package models
+
+// Bundle - several games sold together at a bundle price
+type Bundle struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	Name        string  `gorm:"not null" json:"name" validate:"required,min=1,max=200"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `gorm:"not null" json:"price" validate:"gte=0"`
+	CreatedByID uint    `gorm:"not null" json:"createdById"`
+	Games       []Game  `gorm:"many2many:bundle_games" json:"games"`
+}
+
+// BundleInput - for create bundle
+type BundleInput struct {
+	Name        string  `json:"name" validate:"required,min=1,max=200"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `json:"price" validate:"gte=0"`
+	GameIDs     []uint  `json:"gameIds" validate:"required,min=2,dive,gte=1"`
+}
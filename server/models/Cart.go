@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Cart - games a user collects before checkout. Anonymous carts have no
+// UserID and are identified by Token until they merge into a user's cart.
+type Cart struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    *uint      `gorm:"uniqueIndex" json:"userId,omitempty"`
+	Token     *string    `gorm:"uniqueIndex" json:"-"`
+	Items     []CartItem `gorm:"foreignKey:CartID" json:"items"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// CartItem - one game in a cart
+type CartItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CartID    uint      `gorm:"not null;uniqueIndex:idx_cart_game" json:"cartId"`
+	GameID    uint      `gorm:"not null;uniqueIndex:idx_cart_game" json:"gameId"`
+	Game      Game      `gorm:"foreignKey:GameID" json:"game"`
+	CreatedAt time.Time `json:"addedAt"`
+}
+
+// CartItemInput - add a game to the cart
+type CartItemInput struct {
+	GameID uint `json:"gameId" validate:"required,gte=1"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "fmt"
+
+// GameExternalID is the external ID of a game that didn't come with one
+func GameExternalID(id uint) string {
+	return fmt.Sprintf("game-%d", id)
+}
+
+// CategoryExternalID is the external ID of a category that didn't come with one
+func CategoryExternalID(id uint) string {
+	return fmt.Sprintf("category-%d", id)
+}
+
+// CatalogColumns - CSV header of catalog import/export files
+var CatalogColumns = []string{
+	"external_id",
+	"name",
+	"description",
+	"price",
+	"category_external_id",
+	"category_name",
+	"developer_id",
+	"image",
+}
+
+// CatalogRow - one game of a catalog import/export, upserted by ExternalID
+type CatalogRow struct {
+	ExternalID         string  `json:"externalId" validate:"required,max=100"`
+	Name               string  `json:"name" validate:"required,min=1,max=200"`
+	Description        string  `json:"description" validate:"max=2000"`
+	Price              float64 `json:"price" validate:"gte=0"`
+	CategoryExternalID string  `json:"categoryExternalId" validate:"required,max=100"`
+	CategoryName       string  `json:"categoryName" validate:"omitempty,min=2,max=100"`
+	DeveloperID        uint    `json:"developerId" validate:"required,gte=1"`
+	Image              string  `json:"image" validate:"max=500"`
+}
+
+// CatalogRowResult - import outcome of a single row
+type CatalogRowResult struct {
+	Row        int      `json:"row"`
+	ExternalID string   `json:"externalId"`
+	Action     string   `json:"action"` // "create", "update" or "error"
+	Errors     []string `json:"errors,omitempty"`
+}
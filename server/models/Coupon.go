@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Coupon discount types
+const (
+	CouponTypePercent = "percent"
+	CouponTypeFixed   = "fixed"
+)
+
+// Coupon - a promo code applied at checkout. With games or categories set
+// it only discounts those; MinSpend counts the discounted games only.
+type Coupon struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Code           string     `gorm:"not null;uniqueIndex" json:"code"`
+	Type           string     `gorm:"not null" json:"type"`
+	Value          float64    `gorm:"not null" json:"value"`
+	Games          []Game     `gorm:"many2many:coupon_games" json:"games"`
+	Categories     []Category `gorm:"many2many:coupon_categories" json:"categories"`
+	MinSpend       float64    `gorm:"not null;default:0" json:"minSpend"`
+	MaxUses        *int       `json:"maxUses,omitempty"`
+	MaxUsesPerUser *int       `json:"maxUsesPerUser,omitempty"`
+	Uses           int        `gorm:"not null;default:0" json:"uses"`
+	StartsAt       *time.Time `json:"startsAt,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	Active         bool       `gorm:"not null;default:true" json:"active"`
+	CreatedByID    uint       `gorm:"not null" json:"createdById"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// CouponRedemption - one use of a coupon by an order
+type CouponRedemption struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CouponID  uint      `gorm:"not null;index" json:"couponId"`
+	UserID    uint      `gorm:"not null;index" json:"userId"`
+	OrderID   uint      `gorm:"not null;uniqueIndex" json:"orderId"`
+	Discount  float64   `gorm:"not null" json:"discount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CouponInput - for create and update coupon
+type CouponInput struct {
+	Code           string     `json:"code" validate:"required,min=3,max=40,alphanum"`
+	Type           string     `json:"type" validate:"required,oneof=percent fixed"`
+	Value          float64    `json:"value" validate:"gt=0"`
+	GameIDs        []uint     `json:"gameIds" validate:"dive,gte=1"`
+	CategoryIDs    []uint     `json:"categoryIds" validate:"dive,gte=1"`
+	MinSpend       float64    `json:"minSpend" validate:"gte=0"`
+	MaxUses        *int       `json:"maxUses" validate:"omitempty,gte=1"`
+	MaxUsesPerUser *int       `json:"maxUsesPerUser" validate:"omitempty,gte=1"`
+	StartsAt       *time.Time `json:"startsAt"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	Active         *bool      `json:"active"`
+}
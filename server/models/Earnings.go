@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Payee types; games of an organization pay the organization, other games
+// their developer
+const (
+	PayeeDeveloper    = "developer"
+	PayeeOrganization = "organization"
+)
+
+// Earning entry kinds
+const (
+	EarningKindSale     = "sale"
+	EarningKindClawback = "clawback"
+	EarningKindPayout   = "payout"
+)
+
+// Payout request statuses
+const (
+	PayoutStatusPending  = "pending"
+	PayoutStatusApproved = "approved"
+	PayoutStatusRejected = "rejected"
+)
+
+// RevenueShare - the platform fee agreed with a developer or organization.
+// Payees without one pay the default fee.
+type RevenueShare struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	PayeeType  string    `gorm:"not null;uniqueIndex:idx_revenue_share_payee" json:"payeeType"`
+	PayeeID    uint      `gorm:"not null;uniqueIndex:idx_revenue_share_payee" json:"payeeId"`
+	FeePercent float64   `gorm:"type:numeric(5,2);not null" json:"feePercent"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// RevenueShareInput - set the platform fee of a payee
+type RevenueShareInput struct {
+	PayeeType  string  `json:"payeeType" validate:"required,oneof=developer organization"`
+	PayeeID    uint    `json:"payeeId" validate:"required,gte=1"`
+	FeePercent float64 `json:"feePercent" validate:"gte=0,lte=100"`
+}
+
+// EarningEntry - one append-only line of a payee's earnings ledger. Sales
+// are positive; clawbacks of refunded sales and payouts are negative.
+type EarningEntry struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	PayeeType   string `gorm:"not null;index:idx_earning_payee" json:"payeeType"`
+	PayeeID     uint   `gorm:"not null;index:idx_earning_payee" json:"payeeId"`
+	Kind        string `gorm:"not null" json:"kind"`
+	GameID      *uint  `gorm:"index" json:"gameId,omitempty"`
+	OrderID     *uint  `gorm:"index" json:"orderId,omitempty"`
+	OrderItemID *uint  `gorm:"index" json:"orderItemId,omitempty"`
+	PayoutID    *uint  `gorm:"index" json:"payoutId,omitempty"`
+	// Gross is the sale amount without tax, Fee the platform's share of it
+	// and Net what the payee earns
+	Gross      float64   `gorm:"type:numeric(12,2);not null;default:0" json:"gross"`
+	FeePercent float64   `gorm:"type:numeric(5,2);not null;default:0" json:"feePercent"`
+	Fee        float64   `gorm:"type:numeric(12,2);not null;default:0" json:"fee"`
+	Net        float64   `gorm:"type:numeric(12,2);not null" json:"net"`
+	CreatedAt  time.Time `gorm:"index" json:"createdAt"`
+}
+
+// BeforeUpdate keeps the ledger append-only
+func (EarningEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// BeforeDelete keeps the ledger append-only
+func (EarningEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// PayoutRequest - a payee asking for their balance to be paid out
+type PayoutRequest struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	PayeeType     string     `gorm:"not null;index:idx_payout_payee" json:"payeeType"`
+	PayeeID       uint       `gorm:"not null;index:idx_payout_payee" json:"payeeId"`
+	Amount        float64    `gorm:"type:numeric(12,2);not null" json:"amount"`
+	Status        string     `gorm:"not null;default:pending;index" json:"status"`
+	RequestedByID uint       `gorm:"not null" json:"requestedById"`
+	ReviewedByID  *uint      `json:"reviewedById,omitempty"`
+	ReviewNote    string     `json:"reviewNote,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ReviewedAt    *time.Time `json:"reviewedAt,omitempty"`
+}
+
+// PayoutRequestInput - amount to pay out; organizationId picks the
+// organization's balance instead of the developer's own
+type PayoutRequestInput struct {
+	OrganizationID *uint   `json:"organizationId"`
+	Amount         float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// PayoutReviewInput - an admin's decision note
+type PayoutReviewInput struct {
+	Note string `json:"note" validate:"max=500"`
+}
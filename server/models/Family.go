@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Family member statuses
+const (
+	FamilyMemberInvited = "invited"
+	FamilyMemberActive  = "active"
+)
+
+// FamilyGroup - a household sharing their libraries. The owner invites and
+// removes members.
+type FamilyGroup struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	OwnerID   uint           `gorm:"not null;uniqueIndex" json:"ownerId"`
+	Name      string         `gorm:"not null" json:"name"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Members   []FamilyMember `gorm:"foreignKey:GroupID" json:"members,omitempty"`
+}
+
+// FamilyMember - a user in a family group. A user belongs to one group at
+// a time; pending invitations don't count.
+type FamilyMember struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	GroupID uint   `gorm:"not null;uniqueIndex:idx_family_member" json:"groupId"`
+	UserID  uint   `gorm:"not null;uniqueIndex:idx_family_member;index" json:"userId"`
+	Status  string `gorm:"not null;default:invited" json:"status"`
+	// SharesLibrary lends the member's owned games to the rest of the family
+	SharesLibrary bool       `gorm:"not null;default:true" json:"sharesLibrary"`
+	InvitedAt     time.Time  `json:"invitedAt"`
+	JoinedAt      *time.Time `json:"joinedAt,omitempty"`
+}
+
+// FamilyGroupInput - create a family group
+type FamilyGroupInput struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+// FamilyInviteInput - invite a user by email
+type FamilyInviteInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// FamilySharingInput - turn lending of the own library on or off
+type FamilySharingInput struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+// FamilyLeaseInput - start or renew playing a shared or own game. LenderID
+// picks whose copy to borrow; empty takes any free one. Owners always get
+// their own copy.
+type FamilyLeaseInput struct {
+	GameID   uint  `json:"gameId" validate:"required,gte=1"`
+	LenderID *uint `json:"lenderId" validate:"omitempty,gte=1"`
+}
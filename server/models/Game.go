@@ -1,14 +1,85 @@
 package models
 
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Game types; everything except GameTypeGame is an add-on of a base game
+const (
+	GameTypeGame       = "game"
+	GameTypeDLC        = "dlc"
+	GameTypeSoundtrack = "soundtrack"
+	GameTypeExpansion  = "expansion"
+)
+
 type Game struct {
 	ID          uint     `gorm:"primaryKey" json:"id"`
 	Name        string   `gorm:"not null" json:"name" validate:"required,min=1,max=200"`
+	Slug        string   `gorm:"uniqueIndex" json:"slug"`
 	Price       float64  `gorm:"not null" json:"price" validate:"required,gte=0"`
 	Description string   `json:"description" validate:"max=2000"`
 	CategoryID  uint     `json:"category_id" validate:"required,gte=1"`
 	Category    Category `gorm:"foreignKey:CategoryID" json:"category"`
 	Image       string   `json:"image"`
 	DeveloperID uint     `json:"developerId" validate:"required,gte=1"`
+
+	OrganizationID *uint         `gorm:"index" json:"organizationId"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+
+	Platforms []GamePlatform `gorm:"foreignKey:GameID" json:"platforms"`
+
+	Type       string `gorm:"not null;default:game" json:"type" validate:"omitempty,oneof=game dlc soundtrack expansion"`
+	BaseGameID *uint  `gorm:"index" json:"baseGameId"`
+
+	// ExternalID identifies the game in a publisher's catalog for bulk import
+	ExternalID *string `gorm:"uniqueIndex" json:"externalId,omitempty"`
+
+	// A discount is active while DiscountPercent > 0 until DiscountEndsAt (if set)
+	DiscountPercent int        `gorm:"not null;default:0" json:"discountPercent" validate:"gte=0,lte=100"`
+	DiscountEndsAt  *time.Time `json:"discountEndsAt,omitempty"`
+
+	// ReleaseDate is when the game comes out; unset means already released
+	ReleaseDate *time.Time `gorm:"index" json:"releaseDate,omitempty"`
+}
+
+// AfterCreate gives games created outside a catalog import an external ID,
+// so they can be exported and imported again
+func (g *Game) AfterCreate(tx *gorm.DB) error {
+	if g.ExternalID != nil {
+		return nil
+	}
+	externalID := GameExternalID(g.ID)
+	if err := tx.Model(g).UpdateColumn("external_id", externalID).Error; err != nil {
+		return err
+	}
+	g.ExternalID = &externalID
+	return nil
+}
+
+// IsAddOn reports whether the game extends a base game
+func (g Game) IsAddOn() bool {
+	return g.BaseGameID != nil
+}
+
+// DiscountActive reports whether the game is on sale at the given time
+func (g Game) DiscountActive(now time.Time) bool {
+	return g.DiscountPercent > 0 && (g.DiscountEndsAt == nil || now.Before(*g.DiscountEndsAt))
+}
+
+// Released reports whether the game is out at the given time
+func (g Game) Released(now time.Time) bool {
+	return g.ReleaseDate == nil || !now.Before(*g.ReleaseDate)
+}
+
+// EffectivePrice is the price after an active discount
+func (g Game) EffectivePrice(now time.Time) float64 {
+	if !g.DiscountActive(now) {
+		return g.Price
+	}
+	return math.Round(g.Price*float64(100-g.DiscountPercent)) / 100
 }
 
 // GameCreateInput - for create game
@@ -18,6 +89,8 @@ type GameCreateInput struct {
 	Description string  `form:"description" validate:"max=2000"`
 	CategoryID  uint    `form:"category_id" validate:"required,gte=1"`
 	DeveloperID uint    `form:"developerId" validate:"required,gte=1"`
+	Type        string  `form:"type" validate:"omitempty,oneof=game dlc soundtrack expansion"`
+	BaseGameID  *uint   `form:"base_game_id" validate:"omitempty,gte=1"`
 }
 
 // GameUpdateInput - for update game
@@ -25,4 +98,6 @@ type GameUpdateInput struct {
 	Name        *string  `form:"name" validate:"omitempty,min=1,max=200"`
 	Price       *float64 `form:"price" validate:"omitempty,gte=0"`
 	Description *string  `form:"description" validate:"omitempty,max=2000"`
+	Type        *string  `form:"type" validate:"omitempty,oneof=game dlc soundtrack expansion"`
+	BaseGameID  *uint    `form:"base_game_id" validate:"omitempty,gte=1"`
 }
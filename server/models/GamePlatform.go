@@ -0,0 +1,38 @@
+package models
+
+// Supported platform identifiers
+const (
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+)
+
+// HardwareSpec - one tier of system requirements
+type HardwareSpec struct {
+	OS        string `json:"os" validate:"max=100"`
+	CPU       string `json:"cpu" validate:"max=200"`
+	RAMMB     int    `json:"ramMb" validate:"gte=0"`
+	GPU       string `json:"gpu" validate:"max=200"`
+	StorageMB int    `json:"storageMb" validate:"gte=0"`
+}
+
+// GamePlatform - a platform the game supports with its requirements
+type GamePlatform struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	GameID      uint         `gorm:"not null;uniqueIndex:idx_game_platform" json:"gameId"`
+	Platform    string       `gorm:"not null;uniqueIndex:idx_game_platform" json:"platform" validate:"required,oneof=windows macos linux"`
+	Minimum     HardwareSpec `gorm:"embedded;embeddedPrefix:min_" json:"minimum"`
+	Recommended HardwareSpec `gorm:"embedded;embeddedPrefix:rec_" json:"recommended"`
+}
+
+// GamePlatformInput - for set platforms and requirements of a game
+type GamePlatformInput struct {
+	Platform    string       `json:"platform" validate:"required,oneof=windows macos linux"`
+	Minimum     HardwareSpec `json:"minimum"`
+	Recommended HardwareSpec `json:"recommended"`
+}
+
+// GamePlatformsInput - full replacement list of game platforms
+type GamePlatformsInput struct {
+	Platforms []GamePlatformInput `json:"platforms" validate:"dive"`
+}
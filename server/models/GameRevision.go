@@ -0,0 +1,149 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// Revision actions
+const (
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionRollback = "rollback"
+	RevisionImport   = "import"
+)
+
+// GameSnapshot - the editable store page fields of a game
+type GameSnapshot struct {
+	Name           string  `json:"name"`
+	Slug           string  `json:"slug"`
+	Description    string  `json:"description"`
+	Price          float64 `json:"price"`
+	Image          string  `json:"image"`
+	CategoryID     uint    `json:"categoryId"`
+	Type           string  `json:"type"`
+	BaseGameID     *uint   `json:"baseGameId"`
+	OrganizationID *uint   `json:"organizationId"`
+
+	DiscountPercent int        `json:"discountPercent"`
+	DiscountEndsAt  *time.Time `json:"discountEndsAt"`
+	ReleaseDate     *time.Time `json:"releaseDate"`
+}
+
+// SnapshotGame captures the store page fields of a game
+func SnapshotGame(g Game) GameSnapshot {
+	// Normalized so values read back from the database compare equal
+	normalize := func(at *time.Time) *time.Time {
+		if at == nil {
+			return nil
+		}
+		t := at.UTC().Truncate(time.Microsecond)
+		return &t
+	}
+
+	return GameSnapshot{
+		Name:           g.Name,
+		Slug:           g.Slug,
+		Description:    g.Description,
+		Price:          g.Price,
+		Image:          g.Image,
+		CategoryID:     g.CategoryID,
+		Type:           g.Type,
+		BaseGameID:     g.BaseGameID,
+		OrganizationID: g.OrganizationID,
+
+		DiscountPercent: g.DiscountPercent,
+		DiscountEndsAt:  normalize(g.DiscountEndsAt),
+		ReleaseDate:     normalize(g.ReleaseDate),
+	}
+}
+
+// ApplyTo copies the snapshot fields onto the game
+func (s GameSnapshot) ApplyTo(g *Game) {
+	g.Name = s.Name
+	g.Slug = s.Slug
+	g.Description = s.Description
+	g.Price = s.Price
+	g.Image = s.Image
+	g.CategoryID = s.CategoryID
+	g.Type = s.Type
+	g.BaseGameID = s.BaseGameID
+	g.OrganizationID = s.OrganizationID
+	g.DiscountPercent = s.DiscountPercent
+	g.DiscountEndsAt = s.DiscountEndsAt
+	g.ReleaseDate = s.ReleaseDate
+}
+
+// Value stores the snapshot as JSON
+func (s GameSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the snapshot from JSON
+func (s *GameSnapshot) Scan(value interface{}) error {
+	return scanJSON(value, s)
+}
+
+// FieldChange - old and new value of one field
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// FieldChanges - field diff keyed by JSON field name
+type FieldChanges map[string]FieldChange
+
+// DiffSnapshots returns the fields that differ between two snapshots
+func DiffSnapshots(before, after GameSnapshot) FieldChanges {
+	changes := FieldChanges{}
+	b, a := reflect.ValueOf(before), reflect.ValueOf(after)
+	t := b.Type()
+	for i := 0; i < t.NumField(); i++ {
+		oldValue, newValue := b.Field(i).Interface(), a.Field(i).Interface()
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes[t.Field(i).Tag.Get("json")] = FieldChange{Old: oldValue, New: newValue}
+	}
+	return changes
+}
+
+// Value stores the changes as JSON
+func (c FieldChanges) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan reads the changes from JSON
+func (c *FieldChanges) Scan(value interface{}) error {
+	return scanJSON(value, c)
+}
+
+// GameRevision - one change to a game's store page
+type GameRevision struct {
+	ID         uint         `gorm:"primaryKey" json:"id"`
+	GameID     uint         `gorm:"not null;index" json:"gameId"`
+	EditorID   uint         `gorm:"not null" json:"editorId"`
+	EditorName string       `json:"editorName"`
+	Action     string       `gorm:"not null" json:"action"`
+	Changes    FieldChanges `gorm:"type:jsonb" json:"changes"`
+	Snapshot   GameSnapshot `gorm:"type:jsonb" json:"snapshot"`
+	CreatedAt  time.Time    `json:"createdAt"`
+
+	// RolledBackTo is the revision restored by a rollback
+	RolledBackTo *uint `json:"rolledBackTo,omitempty"`
+}
+
+// scanJSON decodes a JSON column into dest
+func scanJSON(value interface{}, dest interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, dest)
+	case string:
+		return json.Unmarshal([]byte(data), dest)
+	default:
+		return errors.New("unsupported JSON column type")
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Gift statuses
+const (
+	GiftStatusPending  = "pending"
+	GiftStatusAccepted = "accepted"
+	GiftStatusDeclined = "declined"
+)
+
+// Gift - a paid game waiting in the recipient's inbox. Accepting adds it to
+// the library, declining refunds the sender.
+type Gift struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	OrderID     uint       `gorm:"not null;index" json:"orderId"`
+	SenderID    uint       `gorm:"not null;index" json:"senderId"`
+	SenderName  string     `json:"senderName"`
+	RecipientID uint       `gorm:"not null;index" json:"recipientId"`
+	GameID      uint       `gorm:"not null;index" json:"gameId"`
+	Game        Game       `gorm:"foreignKey:GameID" json:"game"`
+	Message     string     `json:"message,omitempty"`
+	Status      string     `gorm:"not null;default:pending;index" json:"status"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	RespondedAt *time.Time `json:"respondedAt,omitempty"`
+}
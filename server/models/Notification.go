@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Notification types
+const (
+	NotificationWishlistSale    = "wishlist_sale"
+	NotificationWishlistRelease = "wishlist_release"
+)
+
+// Notification - an in-app message for a user
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	Type      string     `gorm:"not null" json:"type"`
+	GameID    *uint      `gorm:"index" json:"gameId,omitempty"`
+	Message   string     `gorm:"not null" json:"message"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Order statuses
+const (
+	OrderStatusPending  = "pending"
+	OrderStatusPaid     = "paid"
+	OrderStatusFailed   = "failed"
+	OrderStatusRefunded = "refunded"
+)
+
+// Order - a purchase of one or more games
+type Order struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	UserID     uint        `gorm:"not null;index" json:"userId"`
+	Status     string      `gorm:"not null;default:pending;index" json:"status"`
+	Total      float64     `gorm:"not null" json:"total"`
+	Provider   string      `gorm:"not null" json:"provider"`
+	PaymentRef string      `gorm:"index" json:"paymentRef,omitempty"`
+	Items      []OrderItem `gorm:"foreignKey:OrderID" json:"items"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	UpdatedAt  time.Time   `json:"updatedAt"`
+	PaidAt     *time.Time  `json:"paidAt,omitempty"`
+	// GiftRecipientID is set when the games are bought for another user;
+	// paying the order sends them gifts instead of granting ownership
+	GiftRecipientID *uint  `gorm:"index" json:"giftRecipientId,omitempty"`
+	GiftMessage     string `json:"giftMessage,omitempty"`
+	// Coupon applied at checkout; the code and discount are snapshots
+	CouponID   *uint   `gorm:"index" json:"couponId,omitempty"`
+	CouponCode string  `json:"couponCode,omitempty"`
+	Discount   float64 `gorm:"not null;default:0" json:"discount"`
+	// Tax applied from the buyer's billing location. Inclusive tax is part
+	// of the item prices, exclusive tax is added to Total.
+	Tax          float64 `gorm:"not null;default:0" json:"tax"`
+	TaxName      string  `json:"taxName,omitempty"`
+	TaxRate      float64 `gorm:"not null;default:0" json:"taxRate"`
+	TaxInclusive bool    `gorm:"not null;default:false" json:"taxInclusive"`
+	TaxCountry   string  `gorm:"size:2;index" json:"taxCountry,omitempty"`
+	TaxRegion    string  `json:"taxRegion,omitempty"`
+	// Receipt is issued once the order is paid
+	Receipt *Receipt `gorm:"foreignKey:OrderID" json:"receipt,omitempty"`
+}
+
+// OrderItem - one game of an order. Name and prices are snapshots taken
+// at checkout, later catalog changes don't affect them.
+type OrderItem struct {
+	ID        uint    `gorm:"primaryKey" json:"id"`
+	OrderID   uint    `gorm:"not null;index" json:"orderId"`
+	GameID    uint    `gorm:"not null;index" json:"gameId"`
+	Name      string  `gorm:"not null" json:"name"`
+	ListPrice float64 `gorm:"not null" json:"listPrice"`
+	Price     float64 `gorm:"not null" json:"price"`
+	// CouponDiscount is already taken off Price
+	CouponDiscount float64 `gorm:"not null;default:0" json:"couponDiscount"`
+	// Tax of this item, see Order.TaxInclusive
+	Tax float64 `gorm:"not null;default:0" json:"tax"`
+	// RefundedAt is set once the game of this item was refunded
+	RefundedAt *time.Time `json:"refundedAt,omitempty"`
+}
+
+// Charged returns what the buyer paid for the item, tax included
+func (o Order) Charged(item OrderItem) float64 {
+	if o.TaxInclusive {
+		return item.Price
+	}
+	return item.Price + item.Tax
+}
+
+// CheckoutInput - optional body of checkout endpoints. PaymentMethod names
+// a payment provider, e.g. "wallet"; empty means the default provider.
+type CheckoutInput struct {
+	PaymentMethod string `json:"paymentMethod" validate:"omitempty,max=50"`
+	CouponCode    string `json:"couponCode" validate:"omitempty,max=40"`
+}
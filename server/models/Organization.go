@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Organization member roles
+const (
+	OrgRoleOwner   = "owner"
+	OrgRoleEditor  = "editor"
+	OrgRoleAnalyst = "analyst"
+)
+
+// Organization - a studio or publisher that owns a shared catalog
+type Organization struct {
+	ID          uint                 `gorm:"primaryKey" json:"id"`
+	Name        string               `gorm:"unique;not null" json:"name" validate:"required,min=2,max=100"`
+	Description string               `json:"description" validate:"max=2000"`
+	Website     string               `json:"website" validate:"omitempty,url"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	Members     []OrganizationMember `gorm:"foreignKey:OrganizationID" json:"members,omitempty"`
+}
+
+// OrganizationMember - a user's role within an organization
+type OrganizationMember struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	OrganizationID uint   `gorm:"not null;uniqueIndex:idx_org_member" json:"organizationId"`
+	UserID         uint   `gorm:"not null;uniqueIndex:idx_org_member" json:"userId"`
+	Role           string `gorm:"not null" json:"role" validate:"required,oneof=owner editor analyst"`
+	User           User   `gorm:"foreignKey:UserID" json:"user"`
+}
+
+// OrganizationInput - for create/update organization
+type OrganizationInput struct {
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	Description string `json:"description" validate:"max=2000"`
+	Website     string `json:"website" validate:"omitempty,url"`
+}
+
+// OrganizationMemberInput - for add member or change role
+type OrganizationMemberInput struct {
+	UserID uint   `json:"userId" validate:"required,gte=1"`
+	Role   string `json:"role" validate:"required,oneof=owner editor analyst"`
+}
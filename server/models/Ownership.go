@@ -1,14 +1,64 @@
 package models
 
+import "time"
+
+// Where an owned game came from
+const (
+	OwnershipSourcePurchase = "purchase"
+	OwnershipSourceGift     = "gift"
+	OwnershipSourceKey      = "key"
+)
+
 type Ownership struct {
 	ID     uint   `gorm:"primaryKey" json:"id"`
-	UserID uint   `gorm:"not null" json:"userId"`
-	GameID uint   `gorm:"not null" json:"gameId" validate:"required,gte=1"`
-	Status string `gorm:"not null" json:"status" validate:"required,oneof=owned wishlisted"`
-	Game   Game   `gorm:"foreignKey:GameID" json:"game"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_ownership_user_game" json:"userId"`
+	GameID uint   `gorm:"not null;uniqueIndex:idx_ownership_user_game" json:"gameId" validate:"required,gte=1"`
+	Status string `gorm:"not null" json:"status" validate:"required,oneof=owned wishlisted refunded"`
+	// AcquiredAt is set when the game becomes owned
+	AcquiredAt *time.Time `json:"acquiredAt,omitempty"`
+	// PlaytimeMinutes is counted by play sessions, see PlaySession
+	PlaytimeMinutes int    `gorm:"not null;default:0" json:"playtimeMinutes"`
+	Source          string `gorm:"not null;default:purchase" json:"source"`
+	// GiftID is the gift the game was received with
+	GiftID *uint `json:"giftId,omitempty"`
+	// Wishlist entry details. They stay on the row once the game is bought,
+	// so the library still shows when and why it was wished for.
+	WishlistedAt     *time.Time `json:"wishlistedAt,omitempty"`
+	WishlistPosition int        `gorm:"not null;default:0" json:"wishlistPosition"`
+	WishlistNote     string     `json:"wishlistNote,omitempty"`
+	Game             Game       `gorm:"foreignKey:GameID" json:"game"`
 }
 
 // BuyGameInput - for buy game
 type BuyGameInput struct {
-	GameID uint `json:"gameId" validate:"required,gte=1"`
+	GameID        uint   `json:"gameId" validate:"required,gte=1"`
+	PaymentMethod string `json:"paymentMethod" validate:"omitempty,max=50"`
+	CouponCode    string `json:"couponCode" validate:"omitempty,max=40"`
+	// Buying for another user: name the recipient by ID or email
+	RecipientID    *uint  `json:"recipientId" validate:"omitempty,gte=1"`
+	RecipientEmail string `json:"recipientEmail" validate:"omitempty,email"`
+	GiftMessage    string `json:"giftMessage" validate:"max=500"`
+}
+
+// IsGift reports whether the purchase is for another user
+func (i BuyGameInput) IsGift() bool {
+	return i.RecipientID != nil || i.RecipientEmail != ""
+}
+
+// WishlistInput - add a game to the wishlist
+type WishlistInput struct {
+	GameID uint   `json:"gameId" validate:"required,gte=1"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// WishlistUpdateInput - change the note or move an entry; positions start at 1
+type WishlistUpdateInput struct {
+	Note     *string `json:"note" validate:"omitempty,max=500"`
+	Position *int    `json:"position" validate:"omitempty,gte=1"`
+}
+
+// WishlistOrderInput - game IDs in the wanted order; games left out keep
+// their relative order after them
+type WishlistOrderInput struct {
+	GameIDs []uint `json:"gameIds" validate:"required,min=1,max=1000,dive,gte=1"`
 }
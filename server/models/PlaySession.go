@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PlaySession - a stretch of play timed by the server. The client starts it
+// when the game launches, sends heartbeats while it runs and ends it on
+// exit; only time between server timestamps counts as playtime.
+type PlaySession struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index:idx_play_session_user_game" json:"userId"`
+	GameID     uint       `gorm:"not null;index:idx_play_session_user_game" json:"gameId"`
+	StartedAt  time.Time  `gorm:"not null" json:"startedAt"`
+	LastSeenAt time.Time  `gorm:"not null" json:"lastSeenAt"`
+	EndedAt    *time.Time `json:"endedAt,omitempty"`
+	// Seconds is the playtime the session has counted so far
+	Seconds int `gorm:"not null;default:0" json:"seconds"`
+}
+
+// PlaySessionInput - start playing an owned game
+type PlaySessionInput struct {
+	GameID uint `json:"gameId" validate:"required,gte=1"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// ReceiptLine - one game on a receipt. Discount covers sale prices and
+// promo codes; Price is what was charged before tax.
+type ReceiptLine struct {
+	GameID    uint    `json:"gameId"`
+	Name      string  `json:"name"`
+	ListPrice float64 `json:"listPrice"`
+	Discount  float64 `json:"discount"`
+	Price     float64 `json:"price"`
+	Tax       float64 `json:"tax"`
+}
+
+// ReceiptLines is stored as a JSON column
+type ReceiptLines []ReceiptLine
+
+// Value stores the lines as JSON
+func (l ReceiptLines) Value() (driver.Value, error) {
+	return json.Marshal(l)
+}
+
+// Scan reads the lines from JSON
+func (l *ReceiptLines) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+// Receipt - the numbered document issued when an order is paid.
+// Everything on it is a snapshot, so it never changes afterwards.
+type Receipt struct {
+	ID            uint         `gorm:"primaryKey" json:"id"`
+	Number        string       `gorm:"not null;uniqueIndex" json:"number"`
+	OrderID       uint         `gorm:"not null;uniqueIndex" json:"orderId"`
+	UserID        uint         `gorm:"not null;index" json:"userId"`
+	BillingName   string       `json:"billingName"`
+	BillingEmail  string       `json:"billingEmail"`
+	Lines         ReceiptLines `gorm:"type:jsonb" json:"lines"`
+	Subtotal      float64      `gorm:"not null" json:"subtotal"`
+	Discount      float64      `gorm:"not null" json:"discount"`
+	Tax           float64      `gorm:"not null" json:"tax"`
+	TaxName       string       `json:"taxName,omitempty"`
+	TaxRate       float64      `json:"taxRate"`
+	TaxInclusive  bool         `json:"taxInclusive"`
+	Total         float64      `gorm:"not null" json:"total"`
+	CouponCode    string       `json:"couponCode,omitempty"`
+	PaymentMethod string       `gorm:"not null" json:"paymentMethod"`
+	PaymentRef    string       `json:"paymentRef,omitempty"`
+	IssuedAt      time.Time    `gorm:"not null" json:"issuedAt"`
+}
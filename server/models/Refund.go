@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Refund request statuses
+const (
+	RefundStatusPending  = "pending"
+	RefundStatusApproved = "approved"
+	RefundStatusRejected = "rejected"
+)
+
+// RefundRequest - a request to return a game. Requests within the refund
+// policy are approved right away, others wait for an admin.
+type RefundRequest struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"not null;index" json:"userId"`
+	GameID uint `gorm:"not null;index" json:"gameId"`
+	Game   Game `gorm:"foreignKey:GameID" json:"game"`
+	// OrderID is the paid order the game came from, if any
+	OrderID *uint `gorm:"index" json:"orderId,omitempty"`
+	// Amount is what the buyer was charged for the item, tax included
+	Amount float64 `gorm:"not null;default:0" json:"amount"`
+	Status string  `gorm:"not null;default:pending;index" json:"status"`
+	Reason string  `json:"reason,omitempty"`
+	// PolicyViolations lists the policy rules the request broke, comma separated
+	PolicyViolations string     `json:"policyViolations,omitempty"`
+	PlaytimeMinutes  int        `gorm:"not null;default:0" json:"playtimeMinutes"`
+	PurchasedAt      *time.Time `json:"purchasedAt,omitempty"`
+	ReviewedByID     *uint      `json:"reviewedById,omitempty"`
+	ReviewNote       string     `json:"reviewNote,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	DecidedAt        *time.Time `json:"decidedAt,omitempty"`
+}
+
+// RefundRequestInput - optional body of a refund request
+type RefundRequestInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// RefundReviewInput - an admin's decision note
+type RefundReviewInput struct {
+	Note string `json:"note" validate:"max=500"`
+}
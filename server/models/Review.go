@@ -1,12 +1,22 @@
 package models
 
+import "time"
+
+// Review - a rating left by a user who owns the game, at most one per game
 type Review struct {
 	ID      uint   `gorm:"primaryKey" json:"id"`
-	UserID  uint   `json:"userId"`
-	GameID  uint   `json:"gameId" validate:"required,gte=1"`
+	UserID  uint   `gorm:"not null;uniqueIndex:idx_review_user_game" json:"userId"`
+	GameID  uint   `gorm:"not null;uniqueIndex:idx_review_user_game;index" json:"gameId" validate:"required,gte=1"`
 	User    User   `gorm:"foreignKey:UserID" json:"user"`
 	Rating  int    `json:"rating" validate:"required,gte=1,lte=5"`
 	Comment string `json:"comment" validate:"max=1000"`
+	// PlaytimeMinutes is the author's playtime when the review was last
+	// written, if any was recorded
+	PlaytimeMinutes *int `json:"playtimeMinutes,omitempty"`
+	// Edited is set once the review has been changed after posting
+	Edited    bool      `gorm:"not null;default:false" json:"edited"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // ReviewCreateInput - for create review
@@ -15,3 +25,9 @@ type ReviewCreateInput struct {
 	Rating  int    `json:"rating" validate:"required,gte=1,lte=5"`
 	Comment string `json:"comment" validate:"max=1000"`
 }
+
+// ReviewUpdateInput - for editing a review; omitted fields stay as they are
+type ReviewUpdateInput struct {
+	Rating  *int    `json:"rating" validate:"omitempty,gte=1,lte=5"`
+	Comment *string `json:"comment" validate:"omitempty,max=1000"`
+}
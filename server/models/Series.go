@@ -0,0 +1,31 @@
+package models
+
+// Series - a franchise grouping games in release order
+type Series struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	Name        string        `gorm:"unique;not null" json:"name" validate:"required,min=1,max=200"`
+	Description string        `json:"description" validate:"max=2000"`
+	CreatedByID uint          `gorm:"not null" json:"createdById"`
+	Entries     []SeriesEntry `gorm:"foreignKey:SeriesID" json:"entries,omitempty"`
+}
+
+// SeriesEntry - a game's position within a series; a game belongs to at most one series
+type SeriesEntry struct {
+	ID       uint `gorm:"primaryKey" json:"id"`
+	SeriesID uint `gorm:"not null;index" json:"seriesId"`
+	GameID   uint `gorm:"not null;uniqueIndex" json:"gameId"`
+	Position int  `gorm:"not null" json:"position"`
+	Game     Game `gorm:"foreignKey:GameID" json:"game"`
+}
+
+// SeriesInput - for create series
+type SeriesInput struct {
+	Name        string `json:"name" validate:"required,min=1,max=200"`
+	Description string `json:"description" validate:"max=2000"`
+	GameIDs     []uint `json:"gameIds" validate:"dive,gte=1"`
+}
+
+// SeriesEntriesInput - ordered game list replacing the series entries
+type SeriesEntriesInput struct {
+	GameIDs []uint `json:"gameIds" validate:"required,dive,gte=1"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// Slug entity types
+const (
+	SlugEntityGame     = "game"
+	SlugEntityCategory = "category"
+)
+
+// SlugRedirect - an old slug that now points to the entity's current slug
+type SlugRedirect struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Entity   string `gorm:"not null;uniqueIndex:idx_slug_redirect" json:"entity"`
+	Slug     string `gorm:"not null;uniqueIndex:idx_slug_redirect" json:"slug"`
+	TargetID uint   `gorm:"not null;index" json:"targetId"`
+}
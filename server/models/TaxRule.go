@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TaxRule - the sales tax or VAT of a country, or of one region of it.
+// Inclusive rates are already part of the prices; exclusive ones are added
+// on top at checkout.
+type TaxRule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Country   string    `gorm:"size:2;not null;uniqueIndex:idx_tax_jurisdiction" json:"country"`
+	Region    string    `gorm:"not null;default:'';uniqueIndex:idx_tax_jurisdiction" json:"region"`
+	Name      string    `gorm:"not null" json:"name"`
+	Rate      float64   `gorm:"not null" json:"rate"`
+	Inclusive bool      `gorm:"not null;default:false" json:"inclusive"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TaxRuleInput - for create and update tax rule
+type TaxRuleInput struct {
+	Country   string  `json:"country" validate:"required,len=2,alpha"`
+	Region    string  `json:"region" validate:"max=10"`
+	Name      string  `json:"name" validate:"required,min=1,max=50"`
+	Rate      float64 `json:"rate" validate:"gte=0,lte=100"`
+	Inclusive bool    `json:"inclusive"`
+	Active    *bool   `json:"active"`
+}
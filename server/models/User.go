@@ -11,6 +11,10 @@ type User struct {
 	Role     string `gorm:"not null" json:"role" validate:"required,oneof=user developer admin"`
 	Avatar   string `json:"avatar"`
 	IsBanned bool   `gorm:"default:false" json:"isBanned"`
+	// Billing location, ISO 3166 country and optional region code; decides
+	// the tax on purchases
+	BillingCountry string `gorm:"size:2" json:"billingCountry"`
+	BillingRegion  string `json:"billingRegion"`
 }
 
 // LoginInput - use for valid in Login
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Wallet entry reasons
+const (
+	WalletReasonRefund     = "refund"
+	WalletReasonGiftCard   = "gift_card"
+	WalletReasonPromotion  = "promotion"
+	WalletReasonPurchase   = "purchase"
+	WalletReasonAdjustment = "adjustment"
+)
+
+var ErrLedgerImmutable = errors.New("wallet ledger entries cannot be changed")
+
+// Wallet - a user's store credit. The balance is the sum of its entries;
+// the row itself is locked to serialize debits.
+type Wallet struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex" json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WalletEntry - one append-only ledger line: positive amounts are credits,
+// negative ones debits
+type WalletEntry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WalletID    uint      `gorm:"not null;index" json:"walletId"`
+	Amount      float64   `gorm:"type:numeric(12,2);not null" json:"amount"`
+	Reason      string    `gorm:"not null" json:"reason"`
+	Reference   string    `gorm:"index" json:"reference,omitempty"`
+	CreatedByID *uint     `json:"createdById,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// BeforeUpdate keeps the ledger append-only
+func (WalletEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// BeforeDelete keeps the ledger append-only
+func (WalletEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerImmutable
+}
+
+// WalletEntryInput - manual credit or debit by an admin
+type WalletEntryInput struct {
+	Amount    float64 `json:"amount" validate:"required,ne=0"`
+	Reason    string  `json:"reason" validate:"required,oneof=gift_card promotion adjustment refund"`
+	Reference string  `json:"reference" validate:"max=100"`
+}
@@ -1,11 +1,42 @@
 package models
 
+import "gorm.io/gorm"
+
 type Category struct {
 	ID   uint   `gorm:"primaryKey" json:"id"`
 	Name string `gorm:"not null" json:"name" validate:"required,min=2,max=100"`
+	Slug string `gorm:"uniqueIndex" json:"slug" validate:"omitempty,max=100"`
+
+	// ParentID places the category under another one, nil for top-level categories
+	ParentID *uint      `gorm:"index" json:"parentId"`
+	Children []Category `gorm:"-" json:"children,omitempty"`
+
+	// ExternalID identifies the category in a publisher's catalog for bulk import
+	ExternalID *string `gorm:"uniqueIndex" json:"externalId,omitempty"`
+}
+
+// AfterCreate gives categories created outside a catalog import an external ID
+func (c *Category) AfterCreate(tx *gorm.DB) error {
+	if c.ExternalID != nil {
+		return nil
+	}
+	externalID := CategoryExternalID(c.ID)
+	if err := tx.Model(c).UpdateColumn("external_id", externalID).Error; err != nil {
+		return err
+	}
+	c.ExternalID = &externalID
+	return nil
 }
 
 // CategoryInput - for create/update category
 type CategoryInput struct {
-	Name string `json:"name" validate:"required,min=2,max=100"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Slug     string `json:"slug" validate:"omitempty,max=100"`
+	ParentID *uint  `json:"parentId"`
+}
+
+// CategoryMergeInput - folds source categories into the target one
+type CategoryMergeInput struct {
+	SourceIDs []uint `json:"sourceIds" validate:"required,min=1,dive,gt=0"`
+	TargetID  uint   `json:"targetId" validate:"required"`
 }
@@ -0,0 +1,75 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// FakeProvider settles payments locally, for development and tests.
+// Outcome decides every charge: "paid" (default) and "failed" settle at once,
+// "pending" waits for a webhook signed with Secret.
+type FakeProvider struct {
+	Secret  string
+	Outcome string
+}
+
+// Name of the provider
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// Charge returns a payment with the configured outcome
+func (p *FakeProvider) Charge(charge Charge) (Payment, error) {
+	if charge.Amount < 0 {
+		return Payment{}, errors.New("negative charge amount")
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return Payment{}, err
+	}
+
+	status := StatusPaid
+	switch p.Outcome {
+	case StatusPending, StatusFailed:
+		status = p.Outcome
+	}
+
+	return Payment{
+		Reference: fmt.Sprintf("fake_%d_%s", charge.OrderID, hex.EncodeToString(suffix)),
+		Status:    status,
+	}, nil
+}
+
+// Refund always succeeds
+func (p *FakeProvider) Refund(reference string, amount float64) error {
+	return nil
+}
+
+// Sign returns the signature a webhook payload must carry
+func (p *FakeProvider) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(p.Secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseWebhook checks the HMAC-SHA256 signature and decodes the event
+func (p *FakeProvider) ParseWebhook(payload []byte, signature string) (WebhookEvent, error) {
+	if p.Secret == "" || !hmac.Equal([]byte(p.Sign(payload)), []byte(signature)) {
+		return WebhookEvent{}, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return WebhookEvent{}, err
+	}
+	if event.Reference == "" {
+		return WebhookEvent{}, errors.New("webhook event without payment reference")
+	}
+	return event, nil
+}
@@ -0,0 +1,85 @@
+package payments
+
+import (
+	"errors"
+	"os"
+)
+
+// Payment statuses reported by providers
+const (
+	StatusPending = "pending"
+	StatusPaid    = "paid"
+	StatusFailed  = "failed"
+)
+
+// SignatureHeader carries the webhook payload signature
+const SignatureHeader = "X-Payment-Signature"
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Charge - an amount to collect for an order
+type Charge struct {
+	OrderID uint
+	UserID  uint
+	Amount  float64
+}
+
+// Payment - the provider's answer to a charge
+type Payment struct {
+	Reference   string `json:"reference"`
+	Status      string `json:"status"`
+	CheckoutURL string `json:"checkoutUrl,omitempty"`
+}
+
+// WebhookEvent - a verified asynchronous payment update
+type WebhookEvent struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+}
+
+// Provider - a payment service orders are charged through
+type Provider interface {
+	Name() string
+	Charge(charge Charge) (Payment, error)
+	Refund(reference string, amount float64) error
+	// ParseWebhook verifies the payload signature and decodes the event
+	ParseWebhook(payload []byte, signature string) (WebhookEvent, error)
+}
+
+var (
+	providers       = map[string]Provider{}
+	defaultProvider = "fake"
+)
+
+// Register makes a provider available by name. Call it during startup.
+func Register(p Provider) {
+	providers[p.Name()] = p
+}
+
+// Get returns a registered provider
+func Get(name string) (Provider, bool) {
+	p, ok := providers[name]
+	return p, ok
+}
+
+// Default returns the provider new orders are charged through
+func Default() Provider {
+	return providers[defaultProvider]
+}
+
+// Init registers the built-in providers and picks the default one
+// from PAYMENT_PROVIDER
+func Init() error {
+	Register(&FakeProvider{
+		Secret:  os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+		Outcome: os.Getenv("FAKE_PAYMENT_OUTCOME"),
+	})
+
+	if name := os.Getenv("PAYMENT_PROVIDER"); name != "" {
+		defaultProvider = name
+	}
+	if _, ok := providers[defaultProvider]; !ok {
+		return errors.New("unknown payment provider: " + defaultProvider)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package receipts
+
+import (
+	"awesomeProject/models"
+	"bytes"
+	"fmt"
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"money":    money,
+	"taxLabel": taxLabel,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; max-width: 720px; margin: 2em auto; }
+table { width: 100%; border-collapse: collapse; }
+th, td { padding: 6px 4px; border-bottom: 1px solid #ddd; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.totals td { border: none; }
+.total td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Receipt {{.Number}}</h1>
+<p>
+Issued: {{.IssuedAt.UTC.Format "2006-01-02 15:04 UTC"}}<br>
+Order: #{{.OrderID}}<br>
+Billed to: {{.BillingName}} &lt;{{.BillingEmail}}&gt;<br>
+Payment method: {{.PaymentMethod}}{{if .PaymentRef}} ({{.PaymentRef}}){{end}}
+</p>
+<table>
+<tr><th>Item</th><th>List price</th><th>Discount</th><th>Price</th><th>Tax</th></tr>
+{{range .Lines}}<tr><td>{{.Name}}</td><td>{{money .ListPrice}}</td><td>{{money .Discount}}</td><td>{{money .Price}}</td><td>{{money .Tax}}</td></tr>
+{{end}}</table>
+<table class="totals">
+<tr><td>Subtotal</td><td>{{money .Subtotal}}</td></tr>
+<tr><td>Discount{{if .CouponCode}} (code {{.CouponCode}}){{end}}</td><td>-{{money .Discount}}</td></tr>
+<tr><td>{{taxLabel .}}</td><td>{{money .Tax}}</td></tr>
+<tr class="total"><td>Total</td><td>{{money .Total}}</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// money formats an amount with two decimals
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// taxLabel names the tax of the receipt, e.g. "VAT 20.00% (included)"
+func taxLabel(receipt models.Receipt) string {
+	if receipt.TaxName == "" {
+		return "Tax"
+	}
+	label := fmt.Sprintf("%s %.2f%%", receipt.TaxName, receipt.TaxRate)
+	if receipt.TaxInclusive {
+		label += " (included)"
+	}
+	return label
+}
+
+// HTML renders the receipt as a standalone HTML page
+func HTML(receipt models.Receipt) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, receipt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
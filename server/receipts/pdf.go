@@ -0,0 +1,139 @@
+package receipts
+
+import (
+	"awesomeProject/models"
+	"awesomeProject/utils"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageWidth    = 595 // A4 in points
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 10
+	pdfLineHeight   = 14
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	pdfNameWidth    = 40
+)
+
+// PDF renders the receipt as a plain text PDF in a monospaced font.
+// The built-in PDF fonts only cover Latin-1, so Russian text is
+// transliterated and any other character prints as "?".
+func PDF(receipt models.Receipt) ([]byte, error) {
+	lines := []string{
+		"Receipt " + receipt.Number,
+		"",
+		"Issued:         " + receipt.IssuedAt.UTC().Format("2006-01-02 15:04 UTC"),
+		fmt.Sprintf("Order:          #%d", receipt.OrderID),
+		fmt.Sprintf("Billed to:      %s <%s>", utils.Transliterate(receipt.BillingName), receipt.BillingEmail),
+		"Payment method: " + receipt.PaymentMethod,
+	}
+	if receipt.PaymentRef != "" {
+		lines = append(lines, "Reference:      "+receipt.PaymentRef)
+	}
+	lines = append(lines, "",
+		fmt.Sprintf("%-*s %10s %10s %10s %8s", pdfNameWidth, "Item", "List", "Discount", "Price", "Tax"),
+		strings.Repeat("-", pdfNameWidth+42),
+	)
+	for _, line := range receipt.Lines {
+		lines = append(lines, fmt.Sprintf("%-*s %10s %10s %10s %8s", pdfNameWidth, truncate(utils.Transliterate(line.Name), pdfNameWidth),
+			money(line.ListPrice), money(line.Discount), money(line.Price), money(line.Tax)))
+	}
+
+	discountLabel := "Discount"
+	if receipt.CouponCode != "" {
+		discountLabel += " (code " + receipt.CouponCode + ")"
+	}
+	lines = append(lines,
+		strings.Repeat("-", pdfNameWidth+42),
+		fmt.Sprintf("%-*s %10s", pdfNameWidth+31, "Subtotal", money(receipt.Subtotal)),
+		fmt.Sprintf("%-*s %10s", pdfNameWidth+31, discountLabel, "-"+money(receipt.Discount)),
+		fmt.Sprintf("%-*s %10s", pdfNameWidth+31, utils.Transliterate(taxLabel(receipt)), money(receipt.Tax)),
+		fmt.Sprintf("%-*s %10s", pdfNameWidth+31, "Total", money(receipt.Total)),
+	)
+
+	return textPDF(lines), nil
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// textPDF lays the lines out top to bottom over as many pages as needed
+func textPDF(lines []string) []byte {
+	var pages [][]string
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and its content
+	// stream for every page
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfString(line))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// pdfString escapes a line for a PDF string literal, replacing characters
+// the font can't show
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package refunds
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Reasons a request falls outside the policy
+const (
+	ReasonPurchaseUnknown = "purchase_unknown"
+	ReasonWindowExpired   = "window_expired"
+	ReasonPlaytime        = "playtime_exceeded"
+	ReasonTooManyRefunds  = "too_many_refunds"
+)
+
+// Policy - limits within which refunds are approved automatically.
+// Requests outside them are queued for admin review.
+type Policy struct {
+	// Window is how long after purchase a game can be refunded
+	Window time.Duration
+	// MaxPlaytimeMinutes is the most a refunded game may have been played,
+	// as timed by the server's play sessions
+	MaxPlaytimeMinutes int
+	// MaxRefunds is how many refunds a user gets per CountPeriod
+	MaxRefunds  int
+	CountPeriod time.Duration
+}
+
+// Request - the facts a refund decision is based on
+type Request struct {
+	// PurchasedAt is zero when the purchase time is unknown
+	PurchasedAt     time.Time
+	PlaytimeMinutes int
+	// RecentRefunds counts the user's approved refunds within CountPeriod
+	RecentRefunds int64
+}
+
+// Decision - the outcome of evaluating a request
+type Decision struct {
+	AutoApprove bool     `json:"autoApprove"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+var current = Policy{
+	Window:             14 * 24 * time.Hour,
+	MaxPlaytimeMinutes: 120,
+	MaxRefunds:         5,
+	CountPeriod:        365 * 24 * time.Hour,
+}
+
+// Current returns the active policy
+func Current() Policy {
+	return current
+}
+
+// Evaluate checks the request against the policy
+func (p Policy) Evaluate(req Request, now time.Time) Decision {
+	var reasons []string
+	if req.PurchasedAt.IsZero() {
+		reasons = append(reasons, ReasonPurchaseUnknown)
+	} else if now.Sub(req.PurchasedAt) > p.Window {
+		reasons = append(reasons, ReasonWindowExpired)
+	}
+	if req.PlaytimeMinutes > p.MaxPlaytimeMinutes {
+		reasons = append(reasons, ReasonPlaytime)
+	}
+	if req.RecentRefunds >= int64(p.MaxRefunds) {
+		reasons = append(reasons, ReasonTooManyRefunds)
+	}
+	return Decision{AutoApprove: len(reasons) == 0, Reasons: reasons}
+}
+
+// Init overrides the default policy from REFUND_WINDOW_DAYS,
+// REFUND_MAX_PLAYTIME_MINUTES, REFUND_MAX_COUNT and REFUND_COUNT_PERIOD_DAYS
+func Init() error {
+	settings := []struct {
+		env   string
+		apply func(n int)
+	}{
+		{"REFUND_WINDOW_DAYS", func(n int) { current.Window = time.Duration(n) * 24 * time.Hour }},
+		{"REFUND_MAX_PLAYTIME_MINUTES", func(n int) { current.MaxPlaytimeMinutes = n }},
+		{"REFUND_MAX_COUNT", func(n int) { current.MaxRefunds = n }},
+		{"REFUND_COUNT_PERIOD_DAYS", func(n int) { current.CountPeriod = time.Duration(n) * 24 * time.Hour }},
+	}
+
+	for _, s := range settings {
+		value := os.Getenv(s.env)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s: %q", s.env, value)
+		}
+		s.apply(n)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package refunds
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	policy := Policy{
+		Window:             14 * 24 * time.Hour,
+		MaxPlaytimeMinutes: 120,
+		MaxRefunds:         5,
+		CountPeriod:        365 * 24 * time.Hour,
+	}
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		req     Request
+		reasons []string
+	}{
+		{
+			name: "within limits",
+			req:  Request{PurchasedAt: now.Add(-24 * time.Hour), PlaytimeMinutes: 30, RecentRefunds: 1},
+		},
+		{
+			name: "at the limits",
+			req:  Request{PurchasedAt: now.Add(-policy.Window), PlaytimeMinutes: 120, RecentRefunds: 4},
+		},
+		{
+			name:    "unknown purchase",
+			req:     Request{},
+			reasons: []string{ReasonPurchaseUnknown},
+		},
+		{
+			name:    "window expired",
+			req:     Request{PurchasedAt: now.Add(-policy.Window - time.Second)},
+			reasons: []string{ReasonWindowExpired},
+		},
+		{
+			name:    "played too long",
+			req:     Request{PurchasedAt: now, PlaytimeMinutes: 121},
+			reasons: []string{ReasonPlaytime},
+		},
+		{
+			name:    "too many refunds",
+			req:     Request{PurchasedAt: now, RecentRefunds: 5},
+			reasons: []string{ReasonTooManyRefunds},
+		},
+		{
+			name:    "every reason",
+			req:     Request{PurchasedAt: now.AddDate(0, -1, 0), PlaytimeMinutes: 600, RecentRefunds: 9},
+			reasons: []string{ReasonWindowExpired, ReasonPlaytime, ReasonTooManyRefunds},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(tt.req, now)
+			if !reflect.DeepEqual(decision.Reasons, tt.reasons) {
+				t.Errorf("reasons = %v, want %v", decision.Reasons, tt.reasons)
+			}
+			if decision.AutoApprove != (len(tt.reasons) == 0) {
+				t.Errorf("autoApprove = %v with reasons %v", decision.AutoApprove, decision.Reasons)
+			}
+		})
+	}
+}
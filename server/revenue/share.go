@@ -0,0 +1,39 @@
+package revenue
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// defaultFeePercent is the platform's share of a sale unless a payee has
+// agreed on another one
+var defaultFeePercent = 30.0
+
+// DefaultFeePercent returns the platform fee for payees without their own
+func DefaultFeePercent() float64 {
+	return defaultFeePercent
+}
+
+// Split divides a sale between the platform and the payee, in cents so the
+// two parts always add up to gross
+func Split(gross, feePercent float64) (fee, net float64) {
+	fee = math.Round(gross*feePercent) / 100
+	net = math.Round((gross-fee)*100) / 100
+	return fee, net
+}
+
+// Init overrides the default fee from PLATFORM_FEE_PERCENT
+func Init() error {
+	value := os.Getenv("PLATFORM_FEE_PERCENT")
+	if value == "" {
+		return nil
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return fmt.Errorf("invalid PLATFORM_FEE_PERCENT: %q", value)
+	}
+	defaultFeePercent = percent
+	return nil
+}
@@ -0,0 +1,31 @@
+package revenue
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		gross, percent float64
+		fee, net       float64
+	}{
+		{gross: 10, percent: 30, fee: 3, net: 7},
+		{gross: 19.99, percent: 30, fee: 6, net: 13.99},
+		{gross: 9.99, percent: 15, fee: 1.5, net: 8.49},
+		{gross: 0.01, percent: 30, fee: 0, net: 0.01},
+		{gross: 59.99, percent: 0, fee: 0, net: 59.99},
+		{gross: 59.99, percent: 100, fee: 59.99, net: 0},
+		{gross: 33.33, percent: 12.5, fee: 4.17, net: 29.16},
+	}
+
+	for _, tt := range tests {
+		fee, net := Split(tt.gross, tt.percent)
+		if fee != tt.fee || net != tt.net {
+			t.Errorf("Split(%v, %v) = %v, %v, want %v, %v", tt.gross, tt.percent, fee, net, tt.fee, tt.net)
+		}
+		if math.Abs(fee+net-tt.gross) > 1e-9 {
+			t.Errorf("Split(%v, %v) parts add up to %v", tt.gross, tt.percent, fee+net)
+		}
+	}
+}
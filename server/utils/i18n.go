@@ -0,0 +1,913 @@
+package utils
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MessageCode is a stable, language-independent identifier of an API message
+type MessageCode string
+
+const (
+	// Auth
+	MsgUnauthorized       MessageCode = "unauthorized"
+	MsgInvalidToken       MessageCode = "invalid_token"
+	MsgInvalidTokenClaims MessageCode = "invalid_token_claims"
+	MsgInvalidCredentials MessageCode = "invalid_credentials"
+	MsgAccountBanned      MessageCode = "account_banned"
+	MsgTokenCreateFailed  MessageCode = "token_create_failed"
+	MsgCSRFTokenMissing   MessageCode = "csrf_token_missing"
+	MsgCSRFTokenInvalid   MessageCode = "csrf_token_invalid"
+	MsgCSRFTokenExpired   MessageCode = "csrf_token_expired"
+
+	// Access
+	MsgAdminsOnly           MessageCode = "admins_only"
+	MsgAdminsOrDevelopers   MessageCode = "admins_or_developers_only"
+	MsgAccessDenied         MessageCode = "access_denied"
+	MsgForbidden            MessageCode = "forbidden"
+	MsgOwnGamesOnly         MessageCode = "own_games_only"
+	MsgReviewAuthorOrAdmins MessageCode = "review_author_or_admins_only"
+
+	// Validation
+	MsgValidationFailed      MessageCode = "validation_failed"
+	MsgInvalidRequest        MessageCode = "invalid_request"
+	MsgMissingRequiredFields MessageCode = "missing_required_fields"
+	MsgInvalidGameID         MessageCode = "invalid_game_id"
+	MsgInvalidUserID         MessageCode = "invalid_user_id"
+	MsgInvalidCategoryID     MessageCode = "invalid_category_id"
+	MsgInvalidDeveloperID    MessageCode = "invalid_developer_id"
+	MsgInvalidPrice          MessageCode = "invalid_price"
+	MsgImageRequired         MessageCode = "image_required"
+	MsgGameIDRequired        MessageCode = "game_id_required"
+	MsgSearchQueryRequired   MessageCode = "search_query_required"
+
+	// Not found / conflicts
+	MsgUserNotFound      MessageCode = "user_not_found"
+	MsgGameNotFound      MessageCode = "game_not_found"
+	MsgCategoryNotFound  MessageCode = "category_not_found"
+	MsgReviewNotFound    MessageCode = "review_not_found"
+	MsgOwnershipNotFound MessageCode = "ownership_not_found"
+	MsgNoGamesFound      MessageCode = "no_games_found"
+	MsgEmailExists       MessageCode = "email_exists"
+	MsgGameAlreadyOwned  MessageCode = "game_already_owned"
+
+	// Internal failures
+	MsgHashPasswordFailed  MessageCode = "hash_password_failed"
+	MsgSaveAvatarFailed    MessageCode = "save_avatar_failed"
+	MsgSaveImageFailed     MessageCode = "save_image_failed"
+	MsgFetchUsersFailed    MessageCode = "fetch_users_failed"
+	MsgCreateUserFailed    MessageCode = "create_user_failed"
+	MsgUpdateUserFailed    MessageCode = "update_user_failed"
+	MsgDeleteUserFailed    MessageCode = "delete_user_failed"
+	MsgBanUserFailed       MessageCode = "ban_user_failed"
+	MsgUnbanUserFailed     MessageCode = "unban_user_failed"
+	MsgFetchGamesFailed    MessageCode = "fetch_games_failed"
+	MsgCreateGameFailed    MessageCode = "create_game_failed"
+	MsgUpdateGameFailed    MessageCode = "update_game_failed"
+	MsgDeleteGameFailed    MessageCode = "delete_game_failed"
+	MsgPurchaseFailed      MessageCode = "purchase_failed"
+	MsgFetchLibraryFailed  MessageCode = "fetch_library_failed"
+	MsgDeleteOwnershipFail MessageCode = "delete_ownership_failed"
+	MsgFetchCategoriesFail MessageCode = "fetch_categories_failed"
+	MsgCreateCategoryFail  MessageCode = "create_category_failed"
+	MsgUpdateCategoryFail  MessageCode = "update_category_failed"
+	MsgDeleteCategoryFail  MessageCode = "delete_category_failed"
+	MsgFetchReviewsFailed  MessageCode = "fetch_reviews_failed"
+	MsgCreateReviewFailed  MessageCode = "create_review_failed"
+	MsgDeleteReviewFailed  MessageCode = "delete_review_failed"
+	MsgInternalError       MessageCode = "internal_error"
+
+	// Platforms and system requirements
+	MsgInvalidPlatforms         MessageCode = "invalid_platforms"
+	MsgDuplicatePlatform        MessageCode = "duplicate_platform"
+	MsgInvalidRequirementFilter MessageCode = "invalid_requirement_filter"
+
+	// Add-ons
+	MsgInvalidGameType    MessageCode = "invalid_game_type"
+	MsgBaseGameRequired   MessageCode = "base_game_required"
+	MsgBaseGameNotAllowed MessageCode = "base_game_not_allowed"
+	MsgInvalidBaseGame    MessageCode = "invalid_base_game"
+	MsgBaseGameNotFound   MessageCode = "base_game_not_found"
+	MsgBaseGameNotOwned   MessageCode = "base_game_not_owned"
+	MsgGameHasAddOns      MessageCode = "game_has_add_ons"
+
+	// Bundles
+	MsgBundleNotFound     MessageCode = "bundle_not_found"
+	MsgBundleAlreadyOwned MessageCode = "bundle_already_owned"
+	MsgFetchBundlesFailed MessageCode = "fetch_bundles_failed"
+	MsgCreateBundleFailed MessageCode = "create_bundle_failed"
+	MsgDeleteBundleFailed MessageCode = "delete_bundle_failed"
+
+	// Organizations
+	MsgOrganizationNotFound   MessageCode = "organization_not_found"
+	MsgInvalidOrganizationID  MessageCode = "invalid_organization_id"
+	MsgOrgOwnersOnly          MessageCode = "organization_owners_only"
+	MsgOrgEditorsOnly         MessageCode = "organization_editors_only"
+	MsgOrgMembersOnly         MessageCode = "organization_members_only"
+	MsgOrgMemberNotFound      MessageCode = "organization_member_not_found"
+	MsgOrgLastOwner           MessageCode = "organization_last_owner"
+	MsgFetchOrganizationsFail MessageCode = "fetch_organizations_failed"
+	MsgCreateOrganizationFail MessageCode = "create_organization_failed"
+	MsgUpdateOrganizationFail MessageCode = "update_organization_failed"
+
+	// Series
+	MsgSeriesNotFound     MessageCode = "series_not_found"
+	MsgGameInOtherSeries  MessageCode = "game_in_other_series"
+	MsgFetchSeriesFailed  MessageCode = "fetch_series_failed"
+	MsgCreateSeriesFailed MessageCode = "create_series_failed"
+	MsgUpdateSeriesFailed MessageCode = "update_series_failed"
+	MsgDeleteSeriesFailed MessageCode = "delete_series_failed"
+
+	// Catalog import/export
+	MsgCatalogInvalidFormat     MessageCode = "catalog_invalid_format"
+	MsgCatalogReadFailed        MessageCode = "catalog_read_failed"
+	MsgCatalogInvalidRows       MessageCode = "catalog_invalid_rows"
+	MsgCatalogDuplicateID       MessageCode = "catalog_duplicate_external_id"
+	MsgCatalogDeveloperNotFound MessageCode = "catalog_developer_not_found"
+	MsgCatalogCategoryUnknown   MessageCode = "catalog_category_unknown"
+	MsgCatalogImageNotFound     MessageCode = "catalog_image_not_found"
+	MsgCatalogImportFailed      MessageCode = "catalog_import_failed"
+	MsgCatalogExportFailed      MessageCode = "catalog_export_failed"
+	MsgCatalogMalformedRow      MessageCode = "catalog_malformed_row"
+
+	// Slugs
+	MsgInvalidSlug MessageCode = "invalid_slug"
+	MsgSlugTaken   MessageCode = "slug_taken"
+
+	// Category tree
+	MsgParentCategoryNotFound MessageCode = "parent_category_not_found"
+	MsgCategoryCycle          MessageCode = "category_cycle"
+
+	// Category reassignment and merge
+	MsgCategoryInUse          MessageCode = "category_in_use"
+	MsgTargetCategoryNotFound MessageCode = "target_category_not_found"
+	MsgInvalidTargetCategory  MessageCode = "invalid_target_category"
+	MsgMergeCategoriesFail    MessageCode = "merge_categories_failed"
+
+	// Game revisions
+	MsgRevisionNotFound     MessageCode = "revision_not_found"
+	MsgFetchRevisionsFailed MessageCode = "fetch_revisions_failed"
+	MsgRollbackFailed       MessageCode = "rollback_failed"
+
+	// Orders and payments
+	MsgOrderNotFound           MessageCode = "order_not_found"
+	MsgFetchOrdersFailed       MessageCode = "fetch_orders_failed"
+	MsgPaymentFailed           MessageCode = "payment_failed"
+	MsgPaymentPending          MessageCode = "payment_pending"
+	MsgPaymentProviderNotFound MessageCode = "payment_provider_not_found"
+	MsgInvalidWebhookSignature MessageCode = "invalid_webhook_signature"
+
+	// Discounts and cart
+	MsgInvalidDiscount  MessageCode = "invalid_discount"
+	MsgCartEmpty        MessageCode = "cart_empty"
+	MsgCartChanged      MessageCode = "cart_changed"
+	MsgUpdateCartFailed MessageCode = "update_cart_failed"
+
+	// Wallet
+	MsgInsufficientFunds  MessageCode = "insufficient_funds"
+	MsgFetchWalletFailed  MessageCode = "fetch_wallet_failed"
+	MsgUpdateWalletFailed MessageCode = "update_wallet_failed"
+
+	// Refunds
+	MsgRefundNotFound         MessageCode = "refund_not_found"
+	MsgRefundAlreadyRequested MessageCode = "refund_already_requested"
+	MsgRefundNotPending       MessageCode = "refund_not_pending"
+	MsgGameNotRefundable      MessageCode = "game_not_refundable"
+	MsgRefundFailed           MessageCode = "refund_failed"
+	MsgFetchRefundsFailed     MessageCode = "fetch_refunds_failed"
+	MsgUpdatePlaytimeFailed   MessageCode = "update_playtime_failed"
+
+	// Gifts
+	MsgRecipientNotFound  MessageCode = "recipient_not_found"
+	MsgCannotGiftSelf     MessageCode = "cannot_gift_self"
+	MsgRecipientOwnsGame  MessageCode = "recipient_owns_game"
+	MsgGiftPending        MessageCode = "gift_pending"
+	MsgGiftNotFound       MessageCode = "gift_not_found"
+	MsgGiftNotPending     MessageCode = "gift_not_pending"
+	MsgGiftNotRefundable  MessageCode = "gift_not_refundable"
+	MsgFetchGiftsFailed   MessageCode = "fetch_gifts_failed"
+	MsgGiftResponseFailed MessageCode = "gift_response_failed"
+
+	// Activation keys
+	MsgTooManyRequests      MessageCode = "too_many_requests"
+	MsgThrottleUnavailable  MessageCode = "throttle_unavailable"
+	MsgInvalidActivationKey MessageCode = "invalid_activation_key"
+	MsgKeyAlreadyRedeemed   MessageCode = "key_already_redeemed"
+	MsgKeyExpired           MessageCode = "key_expired"
+	MsgKeyBatchExhausted    MessageCode = "key_batch_exhausted"
+	MsgKeyBatchNotFound     MessageCode = "key_batch_not_found"
+	MsgInvalidKeyExpiry     MessageCode = "invalid_key_expiry"
+	MsgGenerateKeysFailed   MessageCode = "generate_keys_failed"
+	MsgRedeemFailed         MessageCode = "redeem_failed"
+	MsgFetchKeysFailed      MessageCode = "fetch_keys_failed"
+
+	// Coupons
+	MsgCouponNotFound      MessageCode = "coupon_not_found"
+	MsgCouponInvalid       MessageCode = "coupon_invalid"
+	MsgCouponExpired       MessageCode = "coupon_expired"
+	MsgCouponNotApplicable MessageCode = "coupon_not_applicable"
+	MsgCouponMinSpend      MessageCode = "coupon_min_spend"
+	MsgCouponExhausted     MessageCode = "coupon_exhausted"
+	MsgCouponUserLimit     MessageCode = "coupon_user_limit"
+	MsgCouponCodeTaken     MessageCode = "coupon_code_taken"
+	MsgInvalidCouponValue  MessageCode = "invalid_coupon_value"
+	MsgInvalidCouponDates  MessageCode = "invalid_coupon_dates"
+	MsgCouponInUse         MessageCode = "coupon_in_use"
+	MsgSaveCouponFailed    MessageCode = "save_coupon_failed"
+	MsgFetchCouponsFailed  MessageCode = "fetch_coupons_failed"
+
+	// Receipts
+	MsgReceiptNotFound      MessageCode = "receipt_not_found"
+	MsgFetchReceiptsFailed  MessageCode = "fetch_receipts_failed"
+	MsgInvalidReceiptFormat MessageCode = "invalid_receipt_format"
+	MsgRenderReceiptFailed  MessageCode = "render_receipt_failed"
+	MsgSendReceiptFailed    MessageCode = "send_receipt_failed"
+
+	// Taxes
+	MsgInvalidBillingCountry MessageCode = "invalid_billing_country"
+	MsgTaxRuleNotFound       MessageCode = "tax_rule_not_found"
+	MsgTaxRuleExists         MessageCode = "tax_rule_exists"
+	MsgSaveTaxRuleFailed     MessageCode = "save_tax_rule_failed"
+	MsgFetchTaxRulesFailed   MessageCode = "fetch_tax_rules_failed"
+	MsgInvalidReportPeriod   MessageCode = "invalid_report_period"
+	MsgInvalidDateRange      MessageCode = "invalid_date_range"
+	MsgTaxReportFailed       MessageCode = "tax_report_failed"
+
+	// Earnings and payouts
+	MsgFetchEarningsFailed    MessageCode = "fetch_earnings_failed"
+	MsgInvalidStatementMonth  MessageCode = "invalid_statement_month"
+	MsgPayoutNotFound         MessageCode = "payout_not_found"
+	MsgPayoutNotPending       MessageCode = "payout_not_pending"
+	MsgInsufficientEarnings   MessageCode = "insufficient_earnings"
+	MsgPayoutRequestFailed    MessageCode = "payout_request_failed"
+	MsgPayoutReviewFailed     MessageCode = "payout_review_failed"
+	MsgRevenueShareNotFound   MessageCode = "revenue_share_not_found"
+	MsgSaveRevenueShareFailed MessageCode = "save_revenue_share_failed"
+
+	// Wishlist and notifications
+	MsgInvalidReleaseDate       MessageCode = "invalid_release_date"
+	MsgAlreadyWishlisted        MessageCode = "already_wishlisted"
+	MsgWishlistEntryNotFound    MessageCode = "wishlist_entry_not_found"
+	MsgFetchWishlistFailed      MessageCode = "fetch_wishlist_failed"
+	MsgUpdateWishlistFailed     MessageCode = "update_wishlist_failed"
+	MsgNotificationNotFound     MessageCode = "notification_not_found"
+	MsgFetchNotificationsFailed MessageCode = "fetch_notifications_failed"
+
+	// Family sharing
+	MsgAlreadyInFamily        MessageCode = "already_in_family"
+	MsgNotInFamily            MessageCode = "not_in_family"
+	MsgFamilyFull             MessageCode = "family_full"
+	MsgFamilyOwnersOnly       MessageCode = "family_owners_only"
+	MsgFamilyOwnerCannotLeave MessageCode = "family_owner_cannot_leave"
+	MsgCannotInviteSelf       MessageCode = "cannot_invite_self"
+	MsgFamilyInviteNotFound   MessageCode = "family_invite_not_found"
+	MsgFamilyMemberNotFound   MessageCode = "family_member_not_found"
+	MsgFetchFamilyFailed      MessageCode = "fetch_family_failed"
+	MsgUpdateFamilyFailed     MessageCode = "update_family_failed"
+	MsgSharedGameNotFound     MessageCode = "shared_game_not_found"
+	MsgSharedGameInUse        MessageCode = "shared_game_in_use"
+	MsgLeasesUnavailable      MessageCode = "leases_unavailable"
+
+	// Idempotency keys
+	MsgInvalidIdempotencyKey MessageCode = "invalid_idempotency_key"
+	MsgIdempotencyKeyReused  MessageCode = "idempotency_key_reused"
+	MsgIdempotencyInProgress MessageCode = "idempotency_in_progress"
+
+	// Reviews
+	MsgReviewRequiresOwnership MessageCode = "review_requires_ownership"
+	MsgReviewExists            MessageCode = "review_exists"
+	MsgReviewAuthorOnly        MessageCode = "review_author_only"
+	MsgUpdateReviewFailed      MessageCode = "update_review_failed"
+
+	// Bulk and parallel operations
+	MsgFetchGameDetailsFailed  MessageCode = "fetch_game_details_failed"
+	MsgBulkUpdateFailed        MessageCode = "bulk_update_failed"
+	MsgSendNotificationsFailed MessageCode = "send_notifications_failed"
+	MsgFetchStatisticsFailed   MessageCode = "fetch_statistics_failed"
+	MsgSearchFailed            MessageCode = "search_failed"
+	MsgValidateGamesFailed     MessageCode = "validate_games_failed"
+	MsgProcessImagesFailed     MessageCode = "process_images_failed"
+
+	// Play sessions
+	MsgPlaySessionNotFound MessageCode = "play_session_not_found"
+	MsgPlaySessionEnded    MessageCode = "play_session_ended"
+)
+
+const DefaultLanguage = "en"
+
+// messageCatalog holds the text of every message code per language
+var messageCatalog = map[string]map[MessageCode]string{
+	"en": {
+		MsgUnauthorized:       "Unauthorized",
+		MsgInvalidToken:       "Invalid token",
+		MsgInvalidTokenClaims: "Invalid token claims",
+		MsgInvalidCredentials: "Invalid email or password",
+		MsgAccountBanned:      "Your account is banned",
+		MsgTokenCreateFailed:  "Failed to create token",
+		MsgCSRFTokenMissing:   "CSRF token missing",
+		MsgCSRFTokenInvalid:   "Invalid CSRF token",
+		MsgCSRFTokenExpired:   "CSRF token expired",
+
+		MsgAdminsOnly:           "Admins only",
+		MsgAdminsOrDevelopers:   "Admins or developers only",
+		MsgAccessDenied:         "Access denied",
+		MsgForbidden:            "Unauthorized",
+		MsgOwnGamesOnly:         "You can only delete your own games",
+		MsgReviewAuthorOrAdmins: "Only admins or review author can delete",
+
+		MsgValidationFailed:      "Validation failed",
+		MsgInvalidRequest:        "Invalid request",
+		MsgMissingRequiredFields: "Missing required fields",
+		MsgInvalidGameID:         "Invalid game ID",
+		MsgInvalidUserID:         "Invalid user ID",
+		MsgInvalidCategoryID:     "Invalid category ID",
+		MsgInvalidDeveloperID:    "Invalid developer ID",
+		MsgInvalidPrice:          "Invalid price",
+		MsgImageRequired:         "Image is required",
+		MsgGameIDRequired:        "gameId is required",
+		MsgSearchQueryRequired:   "Search query is required",
+
+		MsgUserNotFound:      "User not found",
+		MsgGameNotFound:      "Game not found",
+		MsgCategoryNotFound:  "Category not found",
+		MsgReviewNotFound:    "Review not found",
+		MsgOwnershipNotFound: "Ownership not found",
+		MsgNoGamesFound:      "No games found",
+		MsgEmailExists:       "Email already exists",
+		MsgGameAlreadyOwned:  "Game already owned",
+
+		MsgHashPasswordFailed:  "Failed to hash password",
+		MsgSaveAvatarFailed:    "Failed to save avatar",
+		MsgSaveImageFailed:     "Failed to save image",
+		MsgFetchUsersFailed:    "Failed to fetch users",
+		MsgCreateUserFailed:    "Failed to create user",
+		MsgUpdateUserFailed:    "Failed to update user",
+		MsgDeleteUserFailed:    "Failed to delete user",
+		MsgBanUserFailed:       "Failed to ban user",
+		MsgUnbanUserFailed:     "Failed to unban user",
+		MsgFetchGamesFailed:    "Failed to fetch games",
+		MsgCreateGameFailed:    "Failed to create game",
+		MsgUpdateGameFailed:    "Failed to update game",
+		MsgDeleteGameFailed:    "Failed to delete game",
+		MsgPurchaseFailed:      "Failed to purchase game",
+		MsgFetchLibraryFailed:  "Failed to fetch library",
+		MsgDeleteOwnershipFail: "Failed to delete ownership",
+		MsgFetchCategoriesFail: "Failed to fetch categories",
+		MsgCreateCategoryFail:  "Failed to create category",
+		MsgUpdateCategoryFail:  "Failed to update category",
+		MsgDeleteCategoryFail:  "Failed to delete category",
+		MsgFetchReviewsFailed:  "Failed to fetch reviews",
+		MsgCreateReviewFailed:  "Failed to create review",
+		MsgDeleteReviewFailed:  "Failed to delete review",
+		MsgInternalError:       "Internal server error",
+
+		MsgInvalidPlatforms:         "Platforms must be a JSON list of platform requirements",
+		MsgDuplicatePlatform:        "Each platform can be listed only once",
+		MsgInvalidRequirementFilter: "Requirement filters must be non-negative integers",
+
+		MsgInvalidGameType:    "Game type must be one of: game, dlc, soundtrack, expansion",
+		MsgBaseGameRequired:   "Add-ons require a base game",
+		MsgBaseGameNotAllowed: "Only add-ons can have a base game",
+		MsgInvalidBaseGame:    "Base game must be a standalone game",
+		MsgBaseGameNotFound:   "Base game not found",
+		MsgBaseGameNotOwned:   "You must own the base game to buy this add-on",
+		MsgGameHasAddOns:      "Game has add-ons",
+
+		MsgBundleNotFound:     "Bundle not found",
+		MsgBundleAlreadyOwned: "You already own every game in this bundle",
+		MsgFetchBundlesFailed: "Failed to fetch bundles",
+		MsgCreateBundleFailed: "Failed to create bundle",
+		MsgDeleteBundleFailed: "Failed to delete bundle",
+
+		MsgOrganizationNotFound:   "Organization not found",
+		MsgInvalidOrganizationID:  "Invalid organization ID",
+		MsgOrgOwnersOnly:          "Organization owners only",
+		MsgOrgEditorsOnly:         "Organization owners or editors only",
+		MsgOrgMembersOnly:         "Organization members only",
+		MsgOrgMemberNotFound:      "Organization member not found",
+		MsgOrgLastOwner:           "Organization must keep at least one owner",
+		MsgFetchOrganizationsFail: "Failed to fetch organizations",
+		MsgCreateOrganizationFail: "Failed to create organization",
+		MsgUpdateOrganizationFail: "Failed to update organization",
+
+		MsgSeriesNotFound:     "Series not found",
+		MsgGameInOtherSeries:  "Game already belongs to another series",
+		MsgFetchSeriesFailed:  "Failed to fetch series",
+		MsgCreateSeriesFailed: "Failed to create series",
+		MsgUpdateSeriesFailed: "Failed to update series",
+		MsgDeleteSeriesFailed: "Failed to delete series",
+
+		MsgCatalogInvalidFormat:     "Catalog format must be csv or json",
+		MsgCatalogReadFailed:        "Failed to read catalog file",
+		MsgCatalogInvalidRows:       "Catalog contains invalid rows, nothing was imported",
+		MsgCatalogDuplicateID:       "External ID appears more than once in the file",
+		MsgCatalogDeveloperNotFound: "Developer not found",
+		MsgCatalogCategoryUnknown:   "Unknown category external ID, provide a category name to create it",
+		MsgCatalogImageNotFound:     "Image must be an http(s) URL or an existing file in uploads/",
+		MsgCatalogImportFailed:      "Failed to import catalog",
+		MsgCatalogExportFailed:      "Failed to export catalog",
+		MsgCatalogMalformedRow:      "Malformed CSV line",
+
+		MsgInvalidSlug: "Slug may contain only lowercase letters, digits and hyphens, and can't be only digits",
+		MsgSlugTaken:   "Slug is already in use",
+
+		MsgParentCategoryNotFound: "Parent category not found",
+		MsgCategoryCycle:          "A category cannot be moved under itself or its subcategories",
+
+		MsgCategoryInUse:          "Category still has games, pass moveTo with the category to move them to",
+		MsgTargetCategoryNotFound: "Target category not found",
+		MsgInvalidTargetCategory:  "Target category must differ from the categories being removed",
+		MsgMergeCategoriesFail:    "Failed to merge categories",
+
+		MsgRevisionNotFound:     "Revision not found",
+		MsgFetchRevisionsFailed: "Failed to fetch revisions",
+		MsgRollbackFailed:       "Failed to roll back game",
+
+		MsgOrderNotFound:           "Order not found",
+		MsgFetchOrdersFailed:       "Failed to fetch orders",
+		MsgPaymentFailed:           "Payment failed",
+		MsgPaymentPending:          "A payment for this game is already pending",
+		MsgPaymentProviderNotFound: "Payment provider not found",
+		MsgInvalidWebhookSignature: "Invalid webhook signature",
+
+		MsgInvalidDiscount:  "Discount must be a percentage from 0 to 100 with an RFC 3339 end date",
+		MsgCartEmpty:        "Cart is empty",
+		MsgCartChanged:      "Cart changed during checkout, please review it and try again",
+		MsgUpdateCartFailed: "Failed to update cart",
+
+		MsgInsufficientFunds:  "Not enough funds in the wallet",
+		MsgFetchWalletFailed:  "Failed to fetch wallet",
+		MsgUpdateWalletFailed: "Failed to update wallet",
+
+		MsgRefundNotFound:         "Refund request not found",
+		MsgRefundAlreadyRequested: "A refund for this game is already awaiting review",
+		MsgRefundNotPending:       "Refund request has already been decided",
+		MsgGameNotRefundable:      "Only owned games can be refunded",
+		MsgRefundFailed:           "Failed to process refund",
+		MsgFetchRefundsFailed:     "Failed to fetch refund requests",
+		MsgUpdatePlaytimeFailed:   "Failed to record playtime",
+
+		MsgRecipientNotFound:  "Gift recipient not found",
+		MsgCannotGiftSelf:     "You can't send a gift to yourself",
+		MsgRecipientOwnsGame:  "The recipient already owns this game",
+		MsgGiftPending:        "The recipient already has this game waiting as a gift",
+		MsgGiftNotFound:       "Gift not found",
+		MsgGiftNotPending:     "Gift has already been accepted or declined",
+		MsgGiftNotRefundable:  "Gifted games can't be refunded, decline the gift instead",
+		MsgFetchGiftsFailed:   "Failed to fetch gifts",
+		MsgGiftResponseFailed: "Failed to process the gift",
+
+		MsgTooManyRequests:      "Too many attempts, try again later",
+		MsgThrottleUnavailable:  "This is temporarily unavailable, try again later",
+		MsgInvalidActivationKey: "Activation key is not valid",
+		MsgKeyAlreadyRedeemed:   "Activation key has already been used",
+		MsgKeyExpired:           "Activation key has expired",
+		MsgKeyBatchExhausted:    "No more keys of this batch can be redeemed",
+		MsgKeyBatchNotFound:     "Key batch not found",
+		MsgInvalidKeyExpiry:     "Expiry must be in the future",
+		MsgGenerateKeysFailed:   "Failed to generate activation keys",
+		MsgRedeemFailed:         "Failed to redeem activation key",
+		MsgFetchKeysFailed:      "Failed to fetch activation keys",
+
+		MsgCouponNotFound:      "Promo code not found",
+		MsgCouponInvalid:       "Promo code is not valid",
+		MsgCouponExpired:       "Promo code has expired",
+		MsgCouponNotApplicable: "Promo code doesn't apply to these games",
+		MsgCouponMinSpend:      "Order doesn't reach the promo code's minimum spend",
+		MsgCouponExhausted:     "Promo code has been used up",
+		MsgCouponUserLimit:     "You have already used this promo code",
+		MsgCouponCodeTaken:     "A promo code with this code already exists",
+		MsgInvalidCouponValue:  "Percentage discounts can't exceed 100",
+		MsgInvalidCouponDates:  "Promo code must expire after it starts",
+		MsgCouponInUse:         "Promo code has been redeemed, deactivate it instead",
+		MsgSaveCouponFailed:    "Failed to save promo code",
+		MsgFetchCouponsFailed:  "Failed to fetch promo codes",
+
+		MsgReceiptNotFound:      "Receipt not found",
+		MsgFetchReceiptsFailed:  "Failed to fetch purchase history",
+		MsgInvalidReceiptFormat: "Format must be json, html or pdf",
+		MsgRenderReceiptFailed:  "Failed to render receipt",
+		MsgSendReceiptFailed:    "Failed to send receipt",
+
+		MsgInvalidBillingCountry: "Billing country must be a two-letter country code",
+		MsgTaxRuleNotFound:       "Tax rule not found",
+		MsgTaxRuleExists:         "A tax rule for this country and region already exists",
+		MsgSaveTaxRuleFailed:     "Failed to save tax rule",
+		MsgFetchTaxRulesFailed:   "Failed to fetch tax rules",
+		MsgInvalidReportPeriod:   "Period must be day, month, quarter or year",
+		MsgInvalidDateRange:      "Dates must be YYYY-MM-DD and from must be before to",
+		MsgTaxReportFailed:       "Failed to build tax report",
+
+		MsgFetchEarningsFailed:    "Failed to fetch earnings",
+		MsgInvalidStatementMonth:  "Month must be YYYY-MM",
+		MsgPayoutNotFound:         "Payout request not found",
+		MsgPayoutNotPending:       "Payout request was already reviewed",
+		MsgInsufficientEarnings:   "Amount exceeds the available balance",
+		MsgPayoutRequestFailed:    "Failed to request payout",
+		MsgPayoutReviewFailed:     "Failed to review payout request",
+		MsgRevenueShareNotFound:   "Revenue share not found",
+		MsgSaveRevenueShareFailed: "Failed to save revenue share",
+
+		MsgInvalidReleaseDate:       "Release date must be YYYY-MM-DD or RFC 3339",
+		MsgAlreadyWishlisted:        "Game is already on your wishlist",
+		MsgWishlistEntryNotFound:    "Game is not on your wishlist",
+		MsgFetchWishlistFailed:      "Failed to fetch wishlist",
+		MsgUpdateWishlistFailed:     "Failed to update wishlist",
+		MsgNotificationNotFound:     "Notification not found",
+		MsgFetchNotificationsFailed: "Failed to fetch notifications",
+
+		MsgAlreadyInFamily:        "User is already in a family group",
+		MsgNotInFamily:            "You are not in a family group",
+		MsgFamilyFull:             "Family group has no free places",
+		MsgFamilyOwnersOnly:       "Only the family owner can do this",
+		MsgFamilyOwnerCannotLeave: "The owner can't leave the family, disband it instead",
+		MsgCannotInviteSelf:       "You can't invite yourself",
+		MsgFamilyInviteNotFound:   "Family invitation not found",
+		MsgFamilyMemberNotFound:   "Family member not found",
+		MsgFetchFamilyFailed:      "Failed to fetch family group",
+		MsgUpdateFamilyFailed:     "Failed to update family group",
+		MsgSharedGameNotFound:     "Nobody in your family shares this game",
+		MsgSharedGameInUse:        "Every shared copy of this game is in use",
+		MsgLeasesUnavailable:      "Family sharing is temporarily unavailable",
+
+		MsgInvalidIdempotencyKey: "Idempotency-Key must be 1 to 255 characters",
+		MsgIdempotencyKeyReused:  "Idempotency-Key was already used for a different request",
+		MsgIdempotencyInProgress: "A request with this Idempotency-Key is still being processed",
+
+		MsgReviewRequiresOwnership: "Only owners of the game can review it",
+		MsgReviewExists:            "You have already reviewed this game",
+		MsgReviewAuthorOnly:        "Only the author can edit a review",
+		MsgUpdateReviewFailed:      "Failed to update review",
+
+		MsgFetchGameDetailsFailed:  "Failed to fetch game details",
+		MsgBulkUpdateFailed:        "Failed to process games",
+		MsgSendNotificationsFailed: "Failed to send notifications",
+		MsgFetchStatisticsFailed:   "Failed to calculate statistics",
+		MsgSearchFailed:            "Search failed",
+		MsgValidateGamesFailed:     "Failed to validate games",
+		MsgProcessImagesFailed:     "Failed to process images",
+
+		MsgPlaySessionNotFound: "Play session not found",
+		MsgPlaySessionEnded:    "Play session has already ended",
+	},
+	"ru": {
+		MsgUnauthorized:       "Неавторизован",
+		MsgInvalidToken:       "Недействительный токен",
+		MsgInvalidTokenClaims: "Недействительные данные токена",
+		MsgInvalidCredentials: "Неверный email или пароль",
+		MsgAccountBanned:      "Ваш аккаунт заблокирован",
+		MsgTokenCreateFailed:  "Ошибка создания токена",
+		MsgCSRFTokenMissing:   "Отсутствует CSRF-токен",
+		MsgCSRFTokenInvalid:   "Недействительный CSRF-токен",
+		MsgCSRFTokenExpired:   "Срок действия CSRF-токена истёк",
+
+		MsgAdminsOnly:           "Только для администраторов",
+		MsgAdminsOrDevelopers:   "Только для администраторов и разработчиков",
+		MsgAccessDenied:         "Доступ запрещён",
+		MsgForbidden:            "Недостаточно прав",
+		MsgOwnGamesOnly:         "Вы можете удалять только свои игры",
+		MsgReviewAuthorOrAdmins: "Удалить отзыв может только автор или администратор",
+
+		MsgValidationFailed:      "Ошибка валидации",
+		MsgInvalidRequest:        "Некорректный запрос",
+		MsgMissingRequiredFields: "Не заполнены обязательные поля",
+		MsgInvalidGameID:         "Некорректный ID игры",
+		MsgInvalidUserID:         "Некорректный ID пользователя",
+		MsgInvalidCategoryID:     "Некорректный ID категории",
+		MsgInvalidDeveloperID:    "Некорректный ID разработчика",
+		MsgInvalidPrice:          "Некорректная цена",
+		MsgImageRequired:         "Изображение обязательно",
+		MsgGameIDRequired:        "Параметр gameId обязателен",
+		MsgSearchQueryRequired:   "Укажите поисковый запрос",
+
+		MsgUserNotFound:      "Пользователь не найден",
+		MsgGameNotFound:      "Игра не найдена",
+		MsgCategoryNotFound:  "Категория не найдена",
+		MsgReviewNotFound:    "Отзыв не найден",
+		MsgOwnershipNotFound: "Игра отсутствует в библиотеке",
+		MsgNoGamesFound:      "Игры не найдены",
+		MsgEmailExists:       "Email уже зарегистрирован",
+		MsgGameAlreadyOwned:  "Игра уже куплена",
+
+		MsgHashPasswordFailed:  "Ошибка хеширования пароля",
+		MsgSaveAvatarFailed:    "Не удалось сохранить аватар",
+		MsgSaveImageFailed:     "Не удалось сохранить изображение",
+		MsgFetchUsersFailed:    "Не удалось получить пользователей",
+		MsgCreateUserFailed:    "Не удалось создать пользователя",
+		MsgUpdateUserFailed:    "Не удалось обновить пользователя",
+		MsgDeleteUserFailed:    "Не удалось удалить пользователя",
+		MsgBanUserFailed:       "Не удалось заблокировать пользователя",
+		MsgUnbanUserFailed:     "Не удалось разблокировать пользователя",
+		MsgFetchGamesFailed:    "Не удалось получить игры",
+		MsgCreateGameFailed:    "Не удалось создать игру",
+		MsgUpdateGameFailed:    "Не удалось обновить игру",
+		MsgDeleteGameFailed:    "Не удалось удалить игру",
+		MsgPurchaseFailed:      "Не удалось купить игру",
+		MsgFetchLibraryFailed:  "Не удалось получить библиотеку",
+		MsgDeleteOwnershipFail: "Не удалось вернуть игру",
+		MsgFetchCategoriesFail: "Не удалось получить категории",
+		MsgCreateCategoryFail:  "Не удалось создать категорию",
+		MsgUpdateCategoryFail:  "Не удалось обновить категорию",
+		MsgDeleteCategoryFail:  "Не удалось удалить категорию",
+		MsgFetchReviewsFailed:  "Не удалось получить отзывы",
+		MsgCreateReviewFailed:  "Не удалось создать отзыв",
+		MsgDeleteReviewFailed:  "Не удалось удалить отзыв",
+		MsgInternalError:       "Внутренняя ошибка сервера",
+
+		MsgInvalidPlatforms:         "Платформы должны быть JSON-списком системных требований",
+		MsgDuplicatePlatform:        "Каждую платформу можно указать только один раз",
+		MsgInvalidRequirementFilter: "Фильтры требований должны быть неотрицательными целыми числами",
+
+		MsgInvalidGameType:    "Тип игры должен быть одним из: game, dlc, soundtrack, expansion",
+		MsgBaseGameRequired:   "Для дополнения нужно указать основную игру",
+		MsgBaseGameNotAllowed: "Основную игру можно указать только у дополнения",
+		MsgInvalidBaseGame:    "Основной игрой может быть только самостоятельная игра",
+		MsgBaseGameNotFound:   "Основная игра не найдена",
+		MsgBaseGameNotOwned:   "Для покупки дополнения нужна основная игра",
+		MsgGameHasAddOns:      "У игры есть дополнения",
+
+		MsgBundleNotFound:     "Набор не найден",
+		MsgBundleAlreadyOwned: "У вас уже есть все игры из этого набора",
+		MsgFetchBundlesFailed: "Не удалось получить наборы",
+		MsgCreateBundleFailed: "Не удалось создать набор",
+		MsgDeleteBundleFailed: "Не удалось удалить набор",
+
+		MsgOrganizationNotFound:   "Организация не найдена",
+		MsgInvalidOrganizationID:  "Некорректный ID организации",
+		MsgOrgOwnersOnly:          "Только для владельцев организации",
+		MsgOrgEditorsOnly:         "Только для владельцев и редакторов организации",
+		MsgOrgMembersOnly:         "Только для участников организации",
+		MsgOrgMemberNotFound:      "Участник организации не найден",
+		MsgOrgLastOwner:           "В организации должен остаться хотя бы один владелец",
+		MsgFetchOrganizationsFail: "Не удалось получить организации",
+		MsgCreateOrganizationFail: "Не удалось создать организацию",
+		MsgUpdateOrganizationFail: "Не удалось обновить организацию",
+
+		MsgSeriesNotFound:     "Серия не найдена",
+		MsgGameInOtherSeries:  "Игра уже входит в другую серию",
+		MsgFetchSeriesFailed:  "Не удалось получить серии",
+		MsgCreateSeriesFailed: "Не удалось создать серию",
+		MsgUpdateSeriesFailed: "Не удалось обновить серию",
+		MsgDeleteSeriesFailed: "Не удалось удалить серию",
+
+		MsgCatalogInvalidFormat:     "Формат каталога должен быть csv или json",
+		MsgCatalogReadFailed:        "Не удалось прочитать файл каталога",
+		MsgCatalogInvalidRows:       "В каталоге есть ошибочные строки, ничего не импортировано",
+		MsgCatalogDuplicateID:       "Внешний ID встречается в файле несколько раз",
+		MsgCatalogDeveloperNotFound: "Разработчик не найден",
+		MsgCatalogCategoryUnknown:   "Неизвестный внешний ID категории, укажите название, чтобы создать её",
+		MsgCatalogImageNotFound:     "Изображение должно быть http(s)-ссылкой или существующим файлом в uploads/",
+		MsgCatalogImportFailed:      "Не удалось импортировать каталог",
+		MsgCatalogExportFailed:      "Не удалось экспортировать каталог",
+		MsgCatalogMalformedRow:      "Некорректная строка CSV",
+
+		MsgInvalidSlug: "Слаг может содержать только строчные латинские буквы, цифры и дефисы и не может состоять из одних цифр",
+		MsgSlugTaken:   "Слаг уже используется",
+
+		MsgParentCategoryNotFound: "Родительская категория не найдена",
+		MsgCategoryCycle:          "Категорию нельзя переместить в неё саму или в её подкатегории",
+
+		MsgCategoryInUse:          "В категории есть игры, укажите в moveTo категорию для их переноса",
+		MsgTargetCategoryNotFound: "Целевая категория не найдена",
+		MsgInvalidTargetCategory:  "Целевая категория должна отличаться от удаляемых",
+		MsgMergeCategoriesFail:    "Не удалось объединить категории",
+
+		MsgRevisionNotFound:     "Ревизия не найдена",
+		MsgFetchRevisionsFailed: "Не удалось получить ревизии",
+		MsgRollbackFailed:       "Не удалось откатить игру",
+
+		MsgOrderNotFound:           "Заказ не найден",
+		MsgFetchOrdersFailed:       "Не удалось получить заказы",
+		MsgPaymentFailed:           "Оплата не прошла",
+		MsgPaymentPending:          "Оплата этой игры уже ожидает подтверждения",
+		MsgPaymentProviderNotFound: "Платёжный провайдер не найден",
+		MsgInvalidWebhookSignature: "Недействительная подпись вебхука",
+
+		MsgInvalidDiscount:  "Скидка должна быть процентом от 0 до 100 с датой окончания в формате RFC 3339",
+		MsgCartEmpty:        "Корзина пуста",
+		MsgCartChanged:      "Корзина изменилась во время оформления, проверьте её и повторите попытку",
+		MsgUpdateCartFailed: "Не удалось обновить корзину",
+
+		MsgInsufficientFunds:  "Недостаточно средств в кошельке",
+		MsgFetchWalletFailed:  "Не удалось получить кошелёк",
+		MsgUpdateWalletFailed: "Не удалось обновить кошелёк",
+
+		MsgRefundNotFound:         "Запрос на возврат не найден",
+		MsgRefundAlreadyRequested: "Возврат этой игры уже ожидает рассмотрения",
+		MsgRefundNotPending:       "По запросу на возврат уже принято решение",
+		MsgGameNotRefundable:      "Вернуть можно только купленную игру",
+		MsgRefundFailed:           "Не удалось оформить возврат",
+		MsgFetchRefundsFailed:     "Не удалось получить запросы на возврат",
+		MsgUpdatePlaytimeFailed:   "Не удалось сохранить время в игре",
+
+		MsgRecipientNotFound:  "Получатель подарка не найден",
+		MsgCannotGiftSelf:     "Нельзя отправить подарок самому себе",
+		MsgRecipientOwnsGame:  "У получателя уже есть эта игра",
+		MsgGiftPending:        "Эта игра уже ожидает получателя в подарках",
+		MsgGiftNotFound:       "Подарок не найден",
+		MsgGiftNotPending:     "Подарок уже принят или отклонён",
+		MsgGiftNotRefundable:  "Подаренную игру нельзя вернуть, вместо этого отклоните подарок",
+		MsgFetchGiftsFailed:   "Не удалось получить подарки",
+		MsgGiftResponseFailed: "Не удалось обработать подарок",
+
+		MsgTooManyRequests:      "Слишком много попыток, попробуйте позже",
+		MsgThrottleUnavailable:  "Временно недоступно, попробуйте позже",
+		MsgInvalidActivationKey: "Ключ активации недействителен",
+		MsgKeyAlreadyRedeemed:   "Ключ активации уже использован",
+		MsgKeyExpired:           "Срок действия ключа активации истёк",
+		MsgKeyBatchExhausted:    "Лимит активаций для этой партии ключей исчерпан",
+		MsgKeyBatchNotFound:     "Партия ключей не найдена",
+		MsgInvalidKeyExpiry:     "Срок действия должен быть в будущем",
+		MsgGenerateKeysFailed:   "Не удалось создать ключи активации",
+		MsgRedeemFailed:         "Не удалось активировать ключ",
+		MsgFetchKeysFailed:      "Не удалось получить ключи активации",
+
+		MsgCouponNotFound:      "Промокод не найден",
+		MsgCouponInvalid:       "Промокод недействителен",
+		MsgCouponExpired:       "Срок действия промокода истёк",
+		MsgCouponNotApplicable: "Промокод не распространяется на эти игры",
+		MsgCouponMinSpend:      "Сумма заказа меньше минимальной для промокода",
+		MsgCouponExhausted:     "Промокод больше недоступен",
+		MsgCouponUserLimit:     "Вы уже использовали этот промокод",
+		MsgCouponCodeTaken:     "Промокод с таким кодом уже существует",
+		MsgInvalidCouponValue:  "Скидка в процентах не может превышать 100",
+		MsgInvalidCouponDates:  "Промокод должен истекать позже даты начала",
+		MsgCouponInUse:         "Промокод уже использовался, вместо удаления отключите его",
+		MsgSaveCouponFailed:    "Не удалось сохранить промокод",
+		MsgFetchCouponsFailed:  "Не удалось получить промокоды",
+
+		MsgReceiptNotFound:      "Чек не найден",
+		MsgFetchReceiptsFailed:  "Не удалось получить историю покупок",
+		MsgInvalidReceiptFormat: "Формат должен быть json, html или pdf",
+		MsgRenderReceiptFailed:  "Не удалось сформировать чек",
+		MsgSendReceiptFailed:    "Не удалось отправить чек",
+
+		MsgInvalidBillingCountry: "Страна оплаты должна быть двухбуквенным кодом",
+		MsgTaxRuleNotFound:       "Налоговое правило не найдено",
+		MsgTaxRuleExists:         "Налоговое правило для этой страны и региона уже существует",
+		MsgSaveTaxRuleFailed:     "Не удалось сохранить налоговое правило",
+		MsgFetchTaxRulesFailed:   "Не удалось получить налоговые правила",
+		MsgInvalidReportPeriod:   "Период должен быть day, month, quarter или year",
+		MsgInvalidDateRange:      "Даты должны быть в формате ГГГГ-ММ-ДД, и from должна быть раньше to",
+		MsgTaxReportFailed:       "Не удалось сформировать налоговый отчёт",
+
+		MsgFetchEarningsFailed:    "Не удалось получить доходы",
+		MsgInvalidStatementMonth:  "Месяц должен быть в формате ГГГГ-ММ",
+		MsgPayoutNotFound:         "Запрос на выплату не найден",
+		MsgPayoutNotPending:       "Запрос на выплату уже рассмотрен",
+		MsgInsufficientEarnings:   "Сумма превышает доступный баланс",
+		MsgPayoutRequestFailed:    "Не удалось запросить выплату",
+		MsgPayoutReviewFailed:     "Не удалось рассмотреть запрос на выплату",
+		MsgRevenueShareNotFound:   "Доля дохода не найдена",
+		MsgSaveRevenueShareFailed: "Не удалось сохранить долю дохода",
+
+		MsgInvalidReleaseDate:       "Дата выхода должна быть в формате ГГГГ-ММ-ДД или RFC 3339",
+		MsgAlreadyWishlisted:        "Игра уже в списке желаемого",
+		MsgWishlistEntryNotFound:    "Игры нет в списке желаемого",
+		MsgFetchWishlistFailed:      "Не удалось получить список желаемого",
+		MsgUpdateWishlistFailed:     "Не удалось обновить список желаемого",
+		MsgNotificationNotFound:     "Уведомление не найдено",
+		MsgFetchNotificationsFailed: "Не удалось получить уведомления",
+
+		MsgAlreadyInFamily:        "Пользователь уже состоит в семейной группе",
+		MsgNotInFamily:            "Вы не состоите в семейной группе",
+		MsgFamilyFull:             "В семейной группе нет свободных мест",
+		MsgFamilyOwnersOnly:       "Это может сделать только владелец семейной группы",
+		MsgFamilyOwnerCannotLeave: "Владелец не может покинуть семейную группу, её можно только распустить",
+		MsgCannotInviteSelf:       "Нельзя пригласить самого себя",
+		MsgFamilyInviteNotFound:   "Приглашение в семейную группу не найдено",
+		MsgFamilyMemberNotFound:   "Участник семейной группы не найден",
+		MsgFetchFamilyFailed:      "Не удалось получить семейную группу",
+		MsgUpdateFamilyFailed:     "Не удалось обновить семейную группу",
+		MsgSharedGameNotFound:     "Никто в семье не делится этой игрой",
+		MsgSharedGameInUse:        "Все доступные копии этой игры уже используются",
+		MsgLeasesUnavailable:      "Семейный доступ временно недоступен",
+
+		MsgInvalidIdempotencyKey: "Idempotency-Key должен содержать от 1 до 255 символов",
+		MsgIdempotencyKeyReused:  "Idempotency-Key уже использован для другого запроса",
+		MsgIdempotencyInProgress: "Запрос с этим Idempotency-Key ещё обрабатывается",
+
+		MsgReviewRequiresOwnership: "Оставить отзыв могут только владельцы игры",
+		MsgReviewExists:            "Вы уже оставили отзыв на эту игру",
+		MsgReviewAuthorOnly:        "Редактировать отзыв может только автор",
+		MsgUpdateReviewFailed:      "Не удалось обновить отзыв",
+
+		MsgFetchGameDetailsFailed:  "Не удалось получить сведения об игре",
+		MsgBulkUpdateFailed:        "Не удалось обработать игры",
+		MsgSendNotificationsFailed: "Не удалось отправить уведомления",
+		MsgFetchStatisticsFailed:   "Не удалось рассчитать статистику",
+		MsgSearchFailed:            "Не удалось выполнить поиск",
+		MsgValidateGamesFailed:     "Не удалось проверить игры",
+		MsgProcessImagesFailed:     "Не удалось обработать изображения",
+
+		MsgPlaySessionNotFound: "Игровая сессия не найдена",
+		MsgPlaySessionEnded:    "Игровая сессия уже завершена",
+	},
+}
+
+// ParseAcceptLanguage returns language tags from an Accept-Language header ordered by quality
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, q := part, 1.0
+		if i := strings.Index(part, ";"); i != -1 {
+			tag = strings.TrimSpace(part[:i])
+			if params := strings.TrimSpace(part[i+1:]); strings.HasPrefix(params, "q=") {
+				if parsed, err := strconv.ParseFloat(params[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if tag == "" || q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: strings.ToLower(tag), q: q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// NegotiateLanguage picks the best supported language for an Accept-Language header
+func NegotiateLanguage(header string) string {
+	for _, tag := range ParseAcceptLanguage(header) {
+		if tag == "*" {
+			return DefaultLanguage
+		}
+		base := strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0]
+		if _, ok := messageCatalog[base]; ok {
+			return base
+		}
+	}
+	return DefaultLanguage
+}
+
+// Language returns the negotiated language for the request
+func Language(c *gin.Context) string {
+	if lang, ok := c.Get("lang"); ok {
+		return lang.(string)
+	}
+	lang := NegotiateLanguage(c.GetHeader("Accept-Language"))
+	c.Set("lang", lang)
+	return lang
+}
+
+// Translate renders a message code in the given language, falling back to the default
+func Translate(lang string, code MessageCode) string {
+	if text, ok := messageCatalog[lang][code]; ok {
+		return text
+	}
+	if text, ok := messageCatalog[DefaultLanguage][code]; ok {
+		return text
+	}
+	return string(code)
+}
+
+// Message renders a message code in the request's language
+func Message(c *gin.Context, code MessageCode) string {
+	return Translate(Language(c), code)
+}
+
+// ErrorResponse sends a localized error with its stable code
+func ErrorResponse(c *gin.Context, status int, code MessageCode) {
+	c.JSON(status, gin.H{
+		"error": Message(c, code),
+		"code":  code,
+	})
+}
+
+// BadRequest sends a localized invalid request error with the underlying details
+func BadRequest(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   Message(c, MsgInvalidRequest),
+		"code":    MsgInvalidRequest,
+		"details": err.Error(),
+	})
+}
+
+// LanguageMiddleware negotiates the response language and sets Content-Language
+func LanguageMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := Language(c)
+		c.Header("Content-Language", lang)
+		c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cyrillicTranslit maps Russian letters to their Latin transliteration
+var cyrillicTranslit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Transliterate spells Russian letters in Latin, keeping their case, for
+// output that can't show Cyrillic. Other characters are left as they are.
+func Transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		latin, ok := cyrillicTranslit[lower]
+		switch {
+		case !ok:
+			b.WriteRune(r)
+		case lower != r && latin != "":
+			b.WriteString(strings.ToUpper(latin[:1]) + latin[1:])
+		default:
+			b.WriteString(latin)
+		}
+	}
+	return b.String()
+}
+
+// Slugify converts a name into a lowercase URL slug like "grand-theft-auto-v"
+func Slugify(name string) string {
+	var b strings.Builder
+	pendingDash := false
+
+	for _, r := range strings.ToLower(name) {
+		var part string
+		latin, cyrillic := cyrillicTranslit[r]
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(r)
+		case cyrillic && latin == "":
+			// The hard and soft signs are silent, not word breaks
+			continue
+		case cyrillic:
+			part = latin
+		default:
+			pendingDash = b.Len() > 0
+			continue
+		}
+		if pendingDash {
+			b.WriteByte('-')
+			pendingDash = false
+		}
+		b.WriteString(part)
+	}
+
+	slug := b.String()
+	if len(slug) > 100 {
+		slug = strings.TrimRight(slug[:100], "-")
+	}
+	return slug
+}
+
+// IsNumericSlug reports whether slug is only digits. Such slugs can't be
+// told apart from IDs in URLs, so they are never handed out.
+func IsNumericSlug(slug string) bool {
+	if slug == "" {
+		return false
+	}
+	for _, r := range slug {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
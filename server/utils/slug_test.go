@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Grand Theft Auto V", "grand-theft-auto-v"},
+		{"  Half-Life 2: Episode One  ", "half-life-2-episode-one"},
+		{"DOOM (1993)", "doom-1993"},
+		{"Ведьмак 3: Дикая Охота", "vedmak-3-dikaya-ohota"},
+		{"Щит и Ёж", "schit-i-ezh"},
+		{"Café Ünïcode", "caf-n-code"},
+		{"!!!", ""},
+		{"1984", "1984"},
+		{strings.Repeat("a", 99) + " b", strings.Repeat("a", 99)},
+	}
+
+	for _, tt := range tests {
+		if got := Slugify(tt.name); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Иван Петров", "Ivan Petrov"},
+		{"Щука и Юла", "Schuka i Yula"},
+		{"ЖУК", "ZhUK"},
+		{"Подъезд", "Podezd"},
+		{"Steam Deck, НДС 20%", "Steam Deck, NDS 20%"},
+	}
+
+	for _, tt := range tests {
+		if got := Transliterate(tt.in); got != tt.want {
+			t.Errorf("Transliterate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsNumericSlug(t *testing.T) {
+	tests := []struct {
+		slug string
+		want bool
+	}{
+		{"1984", true},
+		{"0", true},
+		{"", false},
+		{"1984-game", false},
+		{"v2", false},
+		{"-12", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsNumericSlug(tt.slug); got != tt.want {
+			t.Errorf("IsNumericSlug(%q) = %v, want %v", tt.slug, got, tt.want)
+		}
+	}
+}
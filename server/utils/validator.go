@@ -2,14 +2,34 @@ package utils
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
 	"net/http"
 )
 
-var validate *validator.Validate
+var (
+	validate   *validator.Validate
+	translator *ut.UniversalTranslator
+)
 
 func init() {
 	validate = validator.New()
+
+	enLocale := en.New()
+	translator = ut.New(enLocale, enLocale, ru.New())
+
+	enTrans, _ := translator.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(validate, enTrans); err != nil {
+		panic("failed to register en validation translations: " + err.Error())
+	}
+	ruTrans, _ := translator.GetTranslator("ru")
+	if err := ruTranslations.RegisterDefaultTranslations(validate, ruTrans); err != nil {
+		panic("failed to register ru validation translations: " + err.Error())
+	}
 }
 
 // ValidateStruct validates a struct and returns formatted errors
@@ -17,34 +37,26 @@ func ValidateStruct(s interface{}) error {
 	return validate.Struct(s)
 }
 
+// ValidationTranslator returns the validator translator for a language
+func ValidationTranslator(lang string) ut.Translator {
+	trans, _ := translator.FindTranslator(lang, DefaultLanguage)
+	return trans
+}
+
 // ValidationErrorResponse sends a formatted validation error response
 func ValidationErrorResponse(c *gin.Context, err error) {
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		trans := ValidationTranslator(Language(c))
 		errors := make(map[string]string)
 		for _, e := range validationErrors {
-			errors[e.Field()] = formatValidationError(e)
+			errors[e.Field()] = e.Translate(trans)
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"errors": errors})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  Message(c, MsgValidationFailed),
+			"code":   MsgValidationFailed,
+			"errors": errors,
+		})
 		return
 	}
-	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-}
-
-func formatValidationError(e validator.FieldError) string {
-	switch e.Tag() {
-	case "required":
-		return e.Field() + " is required"
-	case "email":
-		return e.Field() + " must be a valid email"
-	case "min":
-		return e.Field() + " must be at least " + e.Param() + " characters"
-	case "max":
-		return e.Field() + " must be at most " + e.Param() + " characters"
-	case "gte":
-		return e.Field() + " must be greater than or equal to " + e.Param()
-	case "lte":
-		return e.Field() + " must be less than or equal to " + e.Param()
-	default:
-		return e.Field() + " is invalid"
-	}
+	BadRequest(c, err)
 }